@@ -0,0 +1,103 @@
+package settings
+
+import "strings"
+
+// selectCommand finds the subcommand (the first argument naming a struct with
+// a cmd tag) and splits the arguments into those before it, for global
+// fields, and those after it, for the fields of the subcommand struct. The
+// first argument is skipped when it is the program name (as in os.Args).
+func (s *settings) selectCommand(osArgs []string, a map[string]string, program bool) {
+	args := s.expandFlags(osArgs, a)
+	s.command, s.globalArgs, s.commandArgs = "", args, nil
+
+	if s.plan == nil || len(s.plan.commands) == 0 {
+		return
+	}
+
+	// the value of a global switch (i.e. --name serve) is never a subcommand
+	takesValue := map[string]bool{}
+	for key, field := range a {
+		if _, ok := s.commandOf(key, field); ok {
+			continue
+		}
+
+		if t, ok := s.fieldType(s.resolvePath(field)); ok && !isBool(t) {
+			takesValue[key] = true
+		}
+	}
+
+	first := 0
+	if program {
+		first = 1
+	}
+
+	for i := first; i < len(args); i++ {
+		if takesValue[args[i]] {
+			i++
+			continue
+		}
+
+		if _, ok := s.plan.commands[args[i]]; ok {
+			s.command = args[i]
+			s.globalArgs, s.commandArgs = args[:i], args[i+1:]
+			return
+		}
+	}
+}
+
+// argsFor returns the argument for an ArgsMap key along with the arguments
+// it is read from: those after the selected subcommand for arguments scoped
+// to it, none for arguments scoped to any other subcommand and those before
+// the subcommand for every other argument
+func (s *settings) argsFor(key string, field string) (string, []string) {
+	_, arg := splitArg(key)
+
+	cmd, ok := s.commandOf(key, field)
+	if !ok {
+		return arg, s.globalArgs
+	}
+
+	if cmd == s.command {
+		return arg, s.commandArgs
+	}
+
+	return arg, nil
+}
+
+// commandOf returns the subcommand that an ArgsMap key is scoped to, either
+// explicitly (i.e. "serve --port") or by the struct containing the field
+func (s *settings) commandOf(key string, field string) (string, bool) {
+	if cmd, _ := splitArg(key); cmd != "" {
+		return cmd, true
+	}
+
+	if s.plan == nil {
+		return "", false
+	}
+
+	return commandFor(s.plan.commands, s.resolvePath(field))
+}
+
+// commandFor returns the subcommand whose struct contains the field (the
+// innermost when cmd tags are nested)
+func commandFor(commands map[string]string, fieldPath string) (string, bool) {
+	cmd, longest := "", 0
+
+	for name, prefix := range commands {
+		if strings.HasPrefix(fieldPath, prefix+".") && len(prefix) > longest {
+			cmd, longest = name, len(prefix)
+		}
+	}
+
+	return cmd, longest > 0
+}
+
+// splitArg splits an ArgsMap key that is scoped to a subcommand (i.e.
+// "serve --port") into the subcommand and the argument
+func splitArg(key string) (string, string) {
+	if cmd, arg, ok := strings.Cut(key, " "); ok {
+		return cmd, arg
+	}
+
+	return "", key
+}
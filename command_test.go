@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testCLIConfig struct {
+	Name    string `arg:"--name"`
+	Port    int    `arg:"--port" env:"PORT"`
+	Verbose bool   `arg:"-v"`
+	Migrate struct {
+		DryRun bool `arg:"--dry-run"`
+		Steps  int  `arg:"--steps"`
+	} `cmd:"migrate"`
+	Serve struct {
+		Port int `arg:"--port" desc:"listen port"`
+		TLS  struct {
+			Cert string `arg:"--cert"`
+		}
+	} `cmd:"serve"`
+}
+
+func TestGather_Commands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    func(*testCLIConfig)
+		command string
+	}{
+		{
+			"should apply global flags without a subcommand",
+			[]string{"svc", "--port", "80", "-v", "--steps", "3"},
+			func(c *testCLIConfig) {
+				c.Port = 80
+				c.Verbose = true
+			},
+			"",
+		},
+		{
+			"should route flags after the subcommand to its struct",
+			[]string{"svc", "-v", "serve", "--port", "8443", "--cert", "server.pem", "--steps", "3"},
+			func(c *testCLIConfig) {
+				c.Verbose = true
+				c.Serve.Port = 8443
+				c.Serve.TLS.Cert = "server.pem"
+			},
+			"serve",
+		},
+		{
+			"should not read global flags after the subcommand",
+			[]string{"svc", "--port", "80", "migrate", "--dry-run", "-v", "--steps=2"},
+			func(c *testCLIConfig) {
+				c.Port = 80
+				c.Migrate.DryRun = true
+				c.Migrate.Steps = 2
+			},
+			"migrate",
+		},
+		{
+			"should not select a subcommand from the value of a switch",
+			[]string{"svc", "--name", "serve", "migrate", "--steps", "1"},
+			func(c *testCLIConfig) {
+				c.Name = "serve"
+				c.Migrate.Steps = 1
+			},
+			"migrate",
+		},
+		{
+			"should not select the program name as a subcommand",
+			[]string{"serve", "--name", "x"},
+			func(c *testCLIConfig) {
+				c.Name = "x"
+			},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testCLIConfig{}
+			r, err := GatherWithReport(Options().SetArgs(tt.args).SetEnv(map[string]string{}), cfg)
+			if err != nil {
+				t.Fatalf("Gather() unexpected error = %v", err)
+			}
+
+			want := &testCLIConfig{}
+			tt.want(want)
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("Gather() = %+v, want %+v", cfg, want)
+			}
+
+			if r.Command != tt.command {
+				t.Errorf("Report.Command = %q, want %q", r.Command, tt.command)
+			}
+		})
+	}
+
+	t.Run("should list subcommand flags in usage", func(t *testing.T) {
+		usage := Usage(&testCLIConfig{}, Options())
+		for _, want := range []string{"Migrate (migrate command):", "Serve (serve command):", "listen port", "Serve.TLS:"} {
+			if !strings.Contains(usage, want) {
+				t.Errorf("Usage() = %s\nmissing %q", usage, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,253 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// escapedDollar stands in for \$ within double quoted values during expansion
+const escapedDollar = "\x00"
+
+type dotenvValue struct {
+	path  string
+	value string
+}
+
+type dotenvError struct {
+	line int
+	msg  string
+}
+
+func (e dotenvError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+// readDotenvFiles parses each dotenv file in order (values in later files
+// replace values in earlier files), files that do not exist are skipped
+func (s *settings) readDotenvFiles(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	if s.dotenv == nil {
+		s.dotenv = map[string]dotenvValue{}
+	}
+
+	for _, path := range paths {
+		found, err := s.statFile(path)
+		if err != nil {
+			if errors.Is(err, ErrFileNotFound) {
+				continue
+			}
+
+			return err
+		}
+
+		for _, fsys := range found {
+			if err := s.readDotenvFile(fsys, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *settings) readDotenvFile(fsys fs.FS, path string) error {
+	in, err := fs.ReadFile(fsys, fsPath(fsys, path))
+	if err != nil {
+		// unable to read the file
+		return fileReadError(path, err)
+	}
+
+	// values from earlier files are visible for expansion
+	vars := map[string]string{}
+	for k, dv := range s.dotenv {
+		vars[k] = dv.value
+	}
+
+	keys, err := parseDotenv(in, vars, func(name string) (string, bool) {
+		v, _ := s.environ(name)
+		return v, v != ""
+	})
+	if err != nil {
+		return fileParseError(path, in, err)
+	}
+
+	for _, k := range keys {
+		s.dotenv[k] = dotenvValue{path: path, value: vars[k]}
+	}
+
+	return nil
+}
+
+// environ looks up an environment variable via ReadOptions.SetLookupEnv,
+// or from the process environment when no lookup func was provided
+func (s *settings) environ(name string) (string, bool) {
+	if s.lookupEnv != nil {
+		return s.lookupEnv(name)
+	}
+
+	return os.LookupEnv(name)
+}
+
+// environNames returns the sorted name of every environment variable (from
+// ReadOptions.SetEnviron or the process environment) and dotenv value
+func (s *settings) environNames() []string {
+	names := map[string]bool{}
+
+	environ := s.environList
+	if environ == nil && s.lookupEnv == nil {
+		environ = os.Environ
+	}
+
+	if environ != nil {
+		for _, kv := range environ() {
+			if name, _, _ := strings.Cut(kv, "="); name != "" {
+				names[name] = true
+			}
+		}
+	}
+
+	for name := range s.dotenv {
+		names[name] = true
+	}
+
+	return sortedKeys(names)
+}
+
+// getenv looks up an environment variable, falling back to the values read
+// from any dotenv files, and returns the source that supplied the value; empty
+// values are only returned (and take precedence) with the EmptyClear policy
+func (s *settings) getenv(name string) (string, Source, string, bool) {
+	if v, ok := s.environ(name); ok && (v != "" || s.emptyValues == EmptyClear) {
+		return v, SourceVars, name, true
+	}
+
+	if dv, ok := s.dotenv[name]; ok && (dv.value != "" || s.emptyValues == EmptyClear) {
+		return dv.value, SourceDotenv, fmt.Sprintf("%s (%s)", name, dv.path), true
+	}
+
+	return "", SourceNone, "", false
+}
+
+// parseDotenv reads KEY=VALUE pairs from the contents of a dotenv file into vars,
+// returning the keys in the order they were defined; comments, an optional export
+// prefix, single quoted (literal) values, double quoted values (with escapes) and
+// ${VAR} expansion in unquoted and double quoted values are supported
+func parseDotenv(in []byte, vars map[string]string, lookup func(string) (string, bool)) ([]string, error) {
+	keys := []string{}
+
+	expand := func(v string) string {
+		v = os.Expand(v, func(name string) string {
+			// real environment variables win over dotenv values
+			if ev, ok := lookup(name); ok {
+				return ev
+			}
+
+			return vars[name]
+		})
+
+		// restore any escaped dollar signs
+		return strings.ReplaceAll(v, escapedDollar, "$")
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(in), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		ln := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		// skip blank lines and comments
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		eq := strings.Index(line, "=")
+		if eq < 1 {
+			return nil, dotenvError{ln, fmt.Sprintf("expected KEY=VALUE but found %q", line)}
+		}
+
+		key := strings.TrimSpace(line[:eq])
+		if strings.ContainsAny(key, " \t'\"") {
+			return nil, dotenvError{ln, fmt.Sprintf("invalid variable name %q", key)}
+		}
+
+		raw := strings.TrimSpace(line[eq+1:])
+		var val string
+
+		switch {
+		case strings.HasPrefix(raw, "'"):
+			// single quoted values are literal
+			end := strings.Index(raw[1:], "'")
+			if end < 0 {
+				return nil, dotenvError{ln, "unterminated single quoted value"}
+			}
+			val = raw[1 : end+1]
+		case strings.HasPrefix(raw, `"`):
+			// double quoted values may span multiple lines
+			v, ok := scanDoubleQuoted(raw[1:])
+			for !ok && i+1 < len(lines) {
+				i++
+				raw = fmt.Sprintf("%s\n%s", raw, lines[i])
+				v, ok = scanDoubleQuoted(raw[1:])
+			}
+			if !ok {
+				return nil, dotenvError{ln, "unterminated double quoted value"}
+			}
+			val = expand(v)
+		default:
+			// strip trailing comments from unquoted values
+			if c := strings.Index(raw, " #"); c >= 0 {
+				raw = raw[:c]
+			}
+			val = expand(strings.TrimSpace(raw))
+		}
+
+		vars[key] = val
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// scanDoubleQuoted reads a double quoted value (without the opening quote),
+// processing escape sequences up until the closing quote
+func scanDoubleQuoted(s string) (string, bool) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '"' {
+			return b.String(), true
+		}
+
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '$':
+				// keep escaped dollar signs from being expanded
+				b.WriteString(escapedDollar)
+			default:
+				b.WriteByte(s[i])
+			}
+
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return "", false
+}
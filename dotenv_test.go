@@ -0,0 +1,181 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		env     map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "should parse unquoted values, comments and export prefix",
+			in: strings.Join([]string{
+				"# database settings",
+				"DATA_HOST=db.internal",
+				"export DATA_PORT = 5432 # inline comment",
+				"",
+				"EMPTY=",
+			}, "\n"),
+			want: map[string]string{
+				"DATA_HOST": "db.internal",
+				"DATA_PORT": "5432",
+				"EMPTY":     "",
+			},
+		},
+		{
+			name: "should parse quoted values",
+			in: strings.Join([]string{
+				`SINGLE='literal ${HOME} # not a comment'`,
+				`DOUBLE="line one\nline \"two\""`,
+				`MULTI="first`,
+				`second"`,
+				`DOLLAR="cost \$5"`,
+			}, "\n"),
+			want: map[string]string{
+				"SINGLE": "literal ${HOME} # not a comment",
+				"DOUBLE": "line one\nline \"two\"",
+				"MULTI":  "first\nsecond",
+				"DOLLAR": "cost $5",
+			},
+		},
+		{
+			name: "should expand variables with the environment taking precedence",
+			in: strings.Join([]string{
+				"HOST=localhost",
+				"USER=dotenv-user",
+				`DSN="postgres://${USER}@${HOST}:$PORT"`,
+			}, "\n"),
+			env: map[string]string{
+				"USER": "env-user",
+				"PORT": "5432",
+			},
+			want: map[string]string{
+				"HOST": "localhost",
+				"USER": "dotenv-user",
+				"DSN":  "postgres://env-user@localhost:5432",
+			},
+		},
+		{
+			name:    "should error when a line is not a key value pair",
+			in:      "VALID=true\nnot a pair",
+			wantErr: "line 2: expected KEY=VALUE",
+		},
+		{
+			name:    "should error on unterminated quotes",
+			in:      "VALUE='oops",
+			wantErr: "line 1: unterminated single quoted value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := map[string]string{}
+			_, err := parseDotenv([]byte(tt.in), vars, func(name string) (string, bool) {
+				v, ok := tt.env[name]
+				return v, ok
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseDotenv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseDotenv() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(vars, tt.want) {
+				t.Errorf("parseDotenv() = %v, want %v", vars, tt.want)
+			}
+		})
+	}
+}
+
+func TestGather_Dotenv(t *testing.T) {
+	type testConfig struct {
+		Host  string `env:"DATA_HOST"`
+		Port  int    `env:"DATA_PORT"`
+		Level string `env:"LOG_LEVEL"`
+		Name  string `yaml:"name"`
+	}
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	localPath := filepath.Join(dir, ".env.local")
+	if err := os.WriteFile(envPath, []byte("DATA_HOST=dotenv-host\nDATA_PORT=5432\nLOG_LEVEL=info\nGO_ENV=simple\n"), 0o600); err != nil {
+		t.Fatalf("unable to write dotenv file: %v", err)
+	}
+	if err := os.WriteFile(localPath, []byte("LOG_LEVEL=debug\n"), 0o600); err != nil {
+		t.Fatalf("unable to write dotenv file: %v", err)
+	}
+
+	opts := Options().
+		SetArgs([]string{}).
+		SetEnv(map[string]string{"DATA_PORT": "6543"}).
+		SetDotenvFiles(envPath, localPath, filepath.Join(dir, ".env.missing")).
+		SetEnvOverride("GO_ENV").
+		SetEnvSearchPaths("./tests")
+
+	cfg := &testConfig{}
+	r, err := GatherWithReport(opts, cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	want := &testConfig{
+		Host:  "dotenv-host",
+		Port:  6543,
+		Level: "debug",
+		Name:  "example",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Gather() = %+v, want %+v", cfg, want)
+	}
+
+	if fr, _ := r.Field("Level"); fr.Source != SourceDotenv || !strings.Contains(fr.Origin, ".env.local") {
+		t.Errorf("Report.Field(Level) = %+v", fr)
+	}
+	if fr, _ := r.Field("Port"); fr.Source != SourceVars {
+		t.Errorf("Report.Field(Port) = %+v", fr)
+	}
+
+	// the process environment is never modified
+	if _, ok := os.LookupEnv("DATA_HOST"); ok {
+		t.Errorf("Gather() should not set dotenv values in the process environment")
+	}
+}
+
+func TestGather_DotenvErrors(t *testing.T) {
+	type testConfig struct {
+		Port int `env:"DATA_PORT"`
+	}
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("DATA_PORT=5432\nbroken\n"), 0o600); err != nil {
+		t.Fatalf("unable to write dotenv file: %v", err)
+	}
+
+	err := Gather(Options().SetDotenvFiles(envPath), &testConfig{})
+	var se SettingsError
+	if !errors.Is(err, ErrFileParse) || !errors.As(err, &se) || se.Line != 2 {
+		t.Fatalf("Gather() expected parse error on line 2, got %v", err)
+	}
+
+	if err := os.WriteFile(envPath, []byte("DATA_PORT=nan\n"), 0o600); err != nil {
+		t.Fatalf("unable to write dotenv file: %v", err)
+	}
+
+	err = Gather(Options().SetDotenvFiles(envPath), &testConfig{})
+	if !errors.As(err, &se) || se.Source != SourceDotenv || !strings.Contains(err.Error(), "DATA_PORT") {
+		t.Fatalf("Gather() expected conversion error from dotenv source, got %v", err)
+	}
+}
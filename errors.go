@@ -1,32 +1,229 @@
 package settings
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var lineRE = regexp.MustCompile(`line (\d+)`)
+
+// ErrorKind classifies a SettingsError so that callers can determine what
+// went wrong without inspecting the error message
+type ErrorKind int
+
+const (
+	// KindUnknown is the zero value for an unclassified SettingsError
+	KindUnknown ErrorKind = iota
+	// KindFieldDoesNotExist is raised when an override targets a missing field
+	KindFieldDoesNotExist
+	// KindFieldTypeMismatch is raised when a default value has the wrong type
+	KindFieldTypeMismatch
+	// KindFieldSet is raised when a value can't be converted or set on a field
+	KindFieldSet
+	// KindFileNotFound is raised when a settings file does not exist
+	KindFileNotFound
+	// KindFileParse is raised when a settings file can't be unmarshalled
+	KindFileParse
+	// KindFileRead is raised when a settings file can't be read
+	KindFileRead
+	// KindFileType is raised when a settings file has an unsupported extension
+	KindFileType
+	// KindOutNil is raised when the out value provided to Gather is nil
+	KindOutNil
+	// KindTypeDiscovery is raised when the out value provided to Gather is not a struct
+	KindTypeDiscovery
+	// KindFieldRequired is raised when no source supplied a value for a required field
+	KindFieldRequired
+	// KindFieldInvalid is raised when a field fails validation
+	KindFieldInvalid
+	// KindHelpRequested is raised when usage was printed for -h or --help
+	KindHelpRequested
+)
+
+// Sentinel errors for use with errors.Is to test the kind of a SettingsError
+var (
+	ErrFieldDoesNotExist = errors.New("settings: field does not exist")
+	ErrFieldInvalid      = errors.New("settings: field is invalid")
+	ErrFieldRequired     = errors.New("settings: required field not set")
+	ErrFieldTypeMismatch = errors.New("settings: field type mismatch")
+	ErrFieldSet          = errors.New("settings: unable to set field")
+	ErrFileNotFound      = errors.New("settings: file not found")
+	ErrFileParse         = errors.New("settings: unable to parse file")
+	ErrFileRead          = errors.New("settings: unable to read file")
+	ErrFileType          = errors.New("settings: unrecognized file type")
+	ErrHelp              = errors.New("settings: help requested")
+	ErrOutNil            = errors.New("settings: out cannot be nil")
+	ErrTypeDiscovery     = errors.New("settings: unable to detect fields")
 )
 
+var kindErrors = map[ErrorKind]error{
+	KindFieldDoesNotExist: ErrFieldDoesNotExist,
+	KindFieldInvalid:      ErrFieldInvalid,
+	KindFieldRequired:     ErrFieldRequired,
+	KindFieldTypeMismatch: ErrFieldTypeMismatch,
+	KindFieldSet:          ErrFieldSet,
+	KindFileNotFound:      ErrFileNotFound,
+	KindFileParse:         ErrFileParse,
+	KindFileRead:          ErrFileRead,
+	KindFileType:          ErrFileType,
+	KindHelpRequested:     ErrHelp,
+	KindOutNil:            ErrOutNil,
+	KindTypeDiscovery:     ErrTypeDiscovery,
+}
+
 // SettingsError is any type of error that is raised specifically related to gathering
 // and applying settings from each of the specified sources
 type SettingsError struct {
+	Kind    ErrorKind
 	Message string
+	Field   string
+	Path    string
+	Source  Source
+	Origin  string
+	Value   string
+	Line    int
+	Column  int
+	Excerpt string
+	Err     error
 }
 
 func (e SettingsError) Error() string {
-	return e.Message
+	msg := e.Message
+
+	if e.Source != SourceNone {
+		if e.Origin == "" {
+			msg = fmt.Sprintf("%s (source: %s)", msg, e.Source)
+		} else {
+			msg = fmt.Sprintf("%s (source: %s %s)", msg, e.Source, e.Origin)
+		}
+	}
+
+	// include the offending lines of a settings file when known
+	if e.Excerpt != "" {
+		msg = fmt.Sprintf("%s\n%s", msg, e.Excerpt)
+	}
+
+	return msg
+}
+
+// Is reports whether target is the sentinel error for the kind of this error
+func (e SettingsError) Is(target error) bool {
+	if e.Kind == KindUnknown {
+		return false
+	}
+
+	return kindErrors[e.Kind] == target
+}
+
+// Unwrap returns the underlying error (i.e. from strconv, yaml, json or os) if any
+func (e SettingsError) Unwrap() error {
+	return e.Err
+}
+
+// SettingsErrors is the collection of errors raised while applying each layer
+// of settings; Gather continues through every layer and reports every
+// problem together rather than stopping at the first
+type SettingsErrors []error
+
+func (e SettingsErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = fmt.Sprintf("\t* %s", err.Error())
+	}
+
+	return fmt.Sprintf(
+		"%d errors occurred while gathering settings:\n%s",
+		len(e),
+		strings.Join(msgs, "\n"))
+}
+
+// Unwrap returns each of the collected errors for use with errors.Is and errors.As
+func (e SettingsErrors) Unwrap() []error {
+	return e
+}
+
+// append adds the error to the collection, flattening any nested SettingsErrors
+func (e SettingsErrors) append(err error) SettingsErrors {
+	if err == nil {
+		return e
+	}
+
+	if errs, ok := err.(SettingsErrors); ok {
+		return append(e, errs...)
+	}
+
+	return append(e, err)
+}
+
+// err returns nil when nothing has been collected so that a typed empty
+// collection is never returned as a non-nil error
+func (e SettingsErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// withSource annotates a SettingsError with the source that supplied the value
+func withSource(err error, src Source, origin string, value string) error {
+	if se, ok := err.(SettingsError); ok {
+		se.Source = src
+		se.Origin = origin
+		se.Value = value
+		return se
+	}
+
+	return err
 }
 
 // SettingsFieldDoesNotExist is an error when a field is specified via the DefaultsMap that does not exist
 // in the out struct value that is provided to settings.Gather
 func SettingsFieldDoesNotExist(overrideType string, fieldName string) SettingsError {
 	return SettingsError{
+		Kind:    KindFieldDoesNotExist,
 		Message: fmt.Sprintf("field specified in override (%s) does not exist in the target out struct: %s", overrideType, fieldName),
+		Field:   fieldName,
+	}
+}
+
+// SettingsFieldInvalid is raised when the value of a field fails a validate rule
+// or when a struct implementing Validator returns an error
+func SettingsFieldInvalid(fieldName string, rule string, err error) SettingsError {
+	return SettingsError{
+		Kind:    KindFieldInvalid,
+		Message: fmt.Sprintf("invalid value for field %s (%s): %s", fieldName, rule, err.Error()),
+		Field:   fieldName,
+		Err:     err,
+	}
+}
+
+// SettingsFieldRequired is raised when a required field is not set by any source
+func SettingsFieldRequired(fieldName string) SettingsError {
+	return SettingsError{
+		Kind:    KindFieldRequired,
+		Message: fmt.Sprintf("required field was not set by any source: %s", fieldName),
+		Field:   fieldName,
 	}
 }
 
 // SettingsFieldTypeMismatch is raised in the event there is a mismatch between types when trying to override a specific value
 func SettingsFieldTypeMismatch(fieldName string, expectedType reflect.Kind, receivedType reflect.Kind) SettingsError {
 	return SettingsError{
+		Kind:    KindFieldTypeMismatch,
 		Message: fmt.Sprintf("type mismatch for field %s: expected %v but value is %v", fieldName, expectedType, receivedType),
+		Field:   fieldName,
 	}
 }
 
@@ -34,43 +231,75 @@ func SettingsFieldTypeMismatch(fieldName string, expectedType reflect.Kind, rece
 func SettingsFieldSetError(fieldName string, t reflect.Kind, m ...error) SettingsError {
 	if len(m) == 0 {
 		return SettingsError{
+			Kind:    KindFieldSet,
 			Message: fmt.Sprintf("unable to set the value of a field in settings: %s (type: %v)", fieldName, t),
+			Field:   fieldName,
 		}
 	}
 
 	return SettingsError{
+		Kind: KindFieldSet,
 		Message: fmt.Sprintf(
 			"unable to set the value of a field in settings: %s (type: %v): %s",
 			fieldName,
 			t,
 			m[0].Error()),
+		Field: fieldName,
+		Err:   m[0],
+	}
+}
+
+// SettingsFileNotFound occurs when a specified settings file does not exist
+func SettingsFileNotFound(path string, err error) SettingsError {
+	return SettingsError{
+		Kind:    KindFileNotFound,
+		Message: fmt.Sprintf("settings file not found (%s): %s", path, err.Error()),
+		Path:    path,
+		Err:     err,
 	}
 }
 
 // SettingsFileParseError occurs when a specified settings file can't be properly unmarshalled
 func SettingsFileParseError(path string, desc string) SettingsError {
 	return SettingsError{
+		Kind:    KindFileParse,
 		Message: fmt.Sprintf("unable to parse settings file (%s): %s", path, desc),
+		Path:    path,
 	}
 }
 
 // SettingsFileReadError occurs when a specified settings file is not readable
 func SettingsFileReadError(path string, desc string) SettingsError {
 	return SettingsError{
+		Kind:    KindFileRead,
 		Message: fmt.Sprintf("unable to read settings file (%s): %s", path, desc),
+		Path:    path,
 	}
 }
 
 // SettingsFileTypeError occurs when a format is requested that the settings package does not support
 func SettingsFileTypeError(path string, ext string) SettingsError {
 	return SettingsError{
+		Kind:    KindFileType,
 		Message: fmt.Sprintf("unrecognized settings file extension (%s): %s", path, ext),
+		Path:    path,
+		Value:   ext,
+	}
+}
+
+// SettingsHelpRequested is raised when -h or --help is provided and usage was
+// printed to ReadOptions.HelpOutput
+func SettingsHelpRequested() SettingsError {
+	return SettingsError{
+		Kind:    KindHelpRequested,
+		Message: "help requested",
 	}
 }
 
 // SettingsOutCannotBeNil occurs when the out field in the settings struct is set to nil, intentionally or otherwise
 func SettingsOutCannotBeNil() SettingsError {
 	return SettingsError{
+		Kind:    KindOutNil,
 		Message: "out cannot be nil",
 	}
 }
@@ -78,6 +307,105 @@ func SettingsOutCannotBeNil() SettingsError {
 // SettingsTypeDiscoveryError occurs when the out value provided to settings.Gather is not a struct
 func SettingsTypeDiscoveryError(t reflect.Kind) SettingsError {
 	return SettingsError{
+		Kind:    KindTypeDiscovery,
 		Message: fmt.Sprintf("unable to detect fields for non-struct type: %v", t),
+		Value:   t.String(),
 	}
 }
+
+// fileParseError wraps the error returned by a decoder as a SettingsFileParseError
+// and locates the line and column of the problem within the file contents
+func fileParseError(path string, in []byte, err error) SettingsError {
+	desc := err.Error()
+	line, col := parsePosition(in, err)
+
+	// JSON errors only describe a byte offset, so describe the position
+	if line > 0 && !lineRE.MatchString(desc) {
+		desc = fmt.Sprintf("%s (line %d, column %d)", desc, line, col)
+	}
+
+	e := SettingsFileParseError(path, desc)
+	e.Line = line
+	e.Column = col
+	e.Excerpt = parseExcerpt(in, line, col)
+	e.Err = err
+	return e
+}
+
+// parsePosition determines the 1-based line and column of a decoder error,
+// the column is 0 when the decoder does not report one
+func parsePosition(in []byte, err error) (int, int) {
+	var offset int64
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tomlErr toml.ParseError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	case errors.As(err, &tomlErr):
+		offset = int64(tomlErr.Position.Start) + 1
+	default:
+		// yaml errors report the line in the message
+		m := lineRE.FindStringSubmatch(err.Error())
+		if m == nil {
+			return 0, 0
+		}
+
+		line, _ := strconv.Atoi(m[1])
+		return line, 0
+	}
+
+	// the offset is the number of bytes read, so the problem is the last byte read
+	if offset < 1 || offset > int64(len(in)) {
+		return 0, 0
+	}
+
+	before := in[:offset-1]
+	line := strings.Count(string(before), "\n") + 1
+	col := len(before) - strings.LastIndex(string(before), "\n")
+
+	return line, col
+}
+
+// parseExcerpt renders the lines surrounding the specified line, marking
+// the offending line (and column, when known)
+func parseExcerpt(in []byte, line int, col int) string {
+	if line < 1 {
+		return ""
+	}
+
+	lines := strings.Split(string(in), "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	first := max(line-1, 1)
+	last := min(line+1, len(lines))
+	width := len(strconv.Itoa(last))
+
+	var b strings.Builder
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+
+		fmt.Fprintf(&b, "%s %*d | %s\n", marker, width, i, strings.TrimRight(lines[i-1], "\r"))
+
+		if i == line && col > 0 {
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", strings.Repeat(" ", col-1))
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// fileReadError wraps the error returned when reading a file as a SettingsFileReadError
+func fileReadError(path string, err error) SettingsError {
+	e := SettingsFileReadError(path, err.Error())
+	e.Err = err
+	return e
+}
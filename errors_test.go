@@ -1,10 +1,16 @@
 package settings
 
 import (
+	"encoding/json"
 	"errors"
+	"io/fs"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
 )
 
 func TestSettingsFieldSetError(t *testing.T) {
@@ -26,3 +32,143 @@ func TestSettingsFileReadError(t *testing.T) {
 		t.Fatalf("SettingsFileReadError() = %v", err)
 	}
 }
+
+func TestSettingsErrors(t *testing.T) {
+	var errs SettingsErrors
+	if errs.err() != nil {
+		t.Fatalf("SettingsErrors.err() expected nil for empty collection")
+	}
+
+	first := withSource(SettingsFieldSetError("Port", reflect.Int, errors.New("bad port")), SourceVars, "PORT", "bad")
+	errs = errs.append(first)
+	if errs.Error() != first.Error() {
+		t.Fatalf("SettingsErrors.Error() with single error = %v, want %v", errs, first)
+	}
+
+	errs = errs.append(SettingsErrors{SettingsFieldDoesNotExist("Args", "Missing")})
+	if len(errs) != 2 {
+		t.Fatalf("SettingsErrors.append() expected nested errors to be flattened, got %d", len(errs))
+	}
+
+	if !strings.Contains(errs.Error(), "2 errors occurred") || !strings.Contains(errs.Error(), "(source: Vars PORT)") {
+		t.Fatalf("SettingsErrors.Error() = %v", errs)
+	}
+
+	var se SettingsError
+	if !errors.As(errs.err(), &se) || se.Field != "Port" || se.Origin != "PORT" || se.Value != "bad" {
+		t.Fatalf("errors.As() SettingsError = %+v", se)
+	}
+}
+
+func TestSettingsError_IsAndUnwrap(t *testing.T) {
+	_, convErr := strconv.Atoi("nan")
+	err := error(SettingsFieldSetError("Count", reflect.Int, convErr))
+
+	if !errors.Is(err, ErrFieldSet) {
+		t.Fatalf("errors.Is(%v, ErrFieldSet) = false", err)
+	}
+	if errors.Is(err, ErrFileParse) {
+		t.Fatalf("errors.Is(%v, ErrFileParse) = true", err)
+	}
+
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Fatalf("errors.As() expected to unwrap *strconv.NumError from %v", err)
+	}
+
+	notFound := error(SettingsFileNotFound("./missing.yml", fs.ErrNotExist))
+	if !errors.Is(notFound, ErrFileNotFound) || !errors.Is(notFound, fs.ErrNotExist) {
+		t.Fatalf("SettingsFileNotFound() should match ErrFileNotFound and fs.ErrNotExist")
+	}
+
+	if errors.Is(SettingsError{Message: "unclassified"}, ErrFieldSet) {
+		t.Fatalf("errors.Is() matched a SettingsError without a kind")
+	}
+
+	tests := []struct {
+		err  SettingsError
+		want error
+	}{
+		{SettingsFieldDoesNotExist("Args", "Name"), ErrFieldDoesNotExist},
+		{SettingsFieldTypeMismatch("Name", reflect.String, reflect.Int), ErrFieldTypeMismatch},
+		{SettingsFileParseError("./config.yml", "bad"), ErrFileParse},
+		{SettingsFileReadError("./config.yml", "bad"), ErrFileRead},
+		{SettingsFileTypeError("./config.ini", ".ini"), ErrFileType},
+		{SettingsOutCannotBeNil(), ErrOutNil},
+		{SettingsTypeDiscoveryError(reflect.String), ErrTypeDiscovery},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.want)
+		}
+	}
+}
+
+func TestFileParseError_Position(t *testing.T) {
+	type testConfig struct {
+		Count int `json:"count" yaml:"count"`
+	}
+
+	tests := []struct {
+		name        string
+		in          string
+		unmarshal   func([]byte, interface{}) error
+		wantLine    int
+		wantColumn  int
+		wantMessage string
+		wantExcerpt string
+	}{
+		{
+			name:        "json syntax error",
+			in:          "{\n  \"name\": \"example\",\n  version: \"1.1\"\n}",
+			unmarshal:   json.Unmarshal,
+			wantLine:    3,
+			wantColumn:  3,
+			wantMessage: "(line 3, column 3)",
+			wantExcerpt: "  2 |   \"name\": \"example\",\n> 3 |   version: \"1.1\"\n    |   ^\n  4 | }",
+		},
+		{
+			name:        "json type error",
+			in:          "{\n  \"count\": \"ten\"\n}",
+			unmarshal:   json.Unmarshal,
+			wantLine:    2,
+			wantColumn:  16,
+			wantMessage: "(line 2, column 16)",
+		},
+		{
+			name:        "toml error",
+			in:          "name = \"example\"\ncount = ten\n",
+			unmarshal:   toml.Unmarshal,
+			wantLine:    2,
+			wantColumn:  9,
+			wantMessage: "line 2",
+		},
+		{
+			name:        "yaml error",
+			in:          "name: example\ncount: ten\nversion: 1.1\n",
+			unmarshal:   yaml.Unmarshal,
+			wantLine:    2,
+			wantMessage: "line 2: cannot unmarshal",
+			wantExcerpt: "  1 | name: example\n> 2 | count: ten\n  3 | version: 1.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := []byte(tt.in)
+			err := fileParseError("./config", in, tt.unmarshal(in, &testConfig{}))
+
+			if err.Line != tt.wantLine || err.Column != tt.wantColumn {
+				t.Errorf("fileParseError() line/column = %d/%d, want %d/%d", err.Line, err.Column, tt.wantLine, tt.wantColumn)
+			}
+			if !strings.Contains(err.Message, tt.wantMessage) {
+				t.Errorf("fileParseError() message = %q, want %q", err.Message, tt.wantMessage)
+			}
+			if tt.wantExcerpt != "" && err.Excerpt != tt.wantExcerpt {
+				t.Errorf("fileParseError() excerpt = \n%s\nwant\n%s", err.Excerpt, tt.wantExcerpt)
+			}
+			if !strings.HasSuffix(err.Error(), err.Excerpt) {
+				t.Errorf("fileParseError() error does not include excerpt: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package settings
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// flagValue is the flag.Value registered by BindFlags for a field; every
+// string provided for the flag is kept so that Gather converts it exactly as
+// it would a command line argument (i.e. repeated flags accumulate for slices)
+type flagValue struct {
+	def    string
+	format valueFormat
+	t      reflect.Type
+	values []string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	if len(v.values) > 0 {
+		return v.values[len(v.values)-1]
+	}
+
+	return v.def
+}
+
+// Set checks that the string can be converted to the field type, so that
+// invalid values are reported by flag.FlagSet.Parse
+func (v *flagValue) Set(sVal string) error {
+	if _, err := parseValueList(v.t, []string{sVal}, v.format); err != nil {
+		return err
+	}
+
+	v.values = append(v.values, sVal)
+	return nil
+}
+
+// IsBoolFlag allows bool fields to be set without a value (i.e. -v)
+func (v *flagValue) IsBoolFlag() bool {
+	return isBool(v.t)
+}
+
+// BindFlags registers a flag in fs for each command line argument mapped to a
+// field of out (via arg tags or opts), using the desc tag as the usage and the
+// default tag or DefaultsMap value as the default. Names already defined in fs
+// are left as they are. After fs.Parse, provide fs via ReadOptions.SetFlagSet
+// so that Gather applies only the flags that were explicitly set.
+func BindFlags(fs *flag.FlagSet, out any, opts ReadOptions) error {
+	s := &settings{
+		fieldTypeMap:  map[string]reflect.Type{},
+		listSeparator: opts.ListSeparator,
+		out:           out,
+	}
+
+	if err := s.determineFieldTypes(); err != nil {
+		return err
+	}
+
+	s.plan.applyTags(&opts)
+	defaults := s.defaultValues(opts)
+
+	var errs SettingsErrors
+
+	// each name mapped to the same field (i.e. -v and --verbose) shares a value
+	values := map[string]*flagValue{}
+
+	for _, arg := range sortedKeys(opts.ArgsMap) {
+		fieldPath := s.resolvePath(opts.ArgsMap[arg])
+		t, ok := s.fieldType(fieldPath)
+		if !ok {
+			errs = errs.append(SettingsFieldDoesNotExist("Args", fieldPath))
+			continue
+		}
+
+		// arguments for a subcommand follow it (after the flags) and are read
+		// by Gather from fs.Args rather than registered
+		name := flagName(arg)
+		if _, ok := s.commandOf(arg, opts.ArgsMap[arg]); ok || name == "" || fs.Lookup(name) != nil {
+			continue
+		}
+
+		fv, ok := values[fieldPath]
+		if !ok {
+			fv = &flagValue{
+				def:    defaults[fieldPath],
+				format: s.valueFormat(fieldPath),
+				t:      t,
+			}
+			values[fieldPath] = fv
+		}
+
+		fs.Var(fv, name, s.flagUsage(fieldPath, t))
+	}
+
+	return errs.err()
+}
+
+// flagUsage returns the usage for the flag of a field: the desc tag (or the
+// field path without one) followed by the type in backquotes, which
+// flag.PrintDefaults shows as the name of the value (i.e. -port int)
+func (s *settings) flagUsage(fieldPath string, t reflect.Type) string {
+	desc := s.plan.descriptions[fieldPath]
+	if desc == "" {
+		desc = fieldPath
+	}
+
+	// switches don't take a value, so the type is left out
+	if isBool(t) {
+		return desc
+	}
+
+	return fmt.Sprintf("%s (`%s`)", desc, s.usageType(fieldPath))
+}
+
+// flagName returns the flag.FlagSet name for an argument (i.e. data-host for --data-host)
+func flagName(arg string) string {
+	return strings.TrimLeft(arg, "-")
+}
+
+// applyFlags applies the value of each flag that was explicitly set in the
+// parsed flag.FlagSet to the field its argument is mapped to, then selects any
+// subcommand from the remaining arguments (fs.Args) and applies those after it
+func (s *settings) applyFlags(fs *flag.FlagSet, a map[string]string) error {
+	set := map[string]*flag.Flag{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f
+	})
+
+	var errs SettingsErrors
+	applied := map[string]bool{}
+
+	for _, arg := range sortedKeys(a) {
+		field := a[arg]
+
+		// arguments scoped to a subcommand aren't registered by BindFlags
+		f, ok := set[flagName(arg)]
+		if _, scoped := s.commandOf(arg, field); scoped || !ok || applied[s.resolvePath(field)] {
+			continue
+		}
+
+		// flags registered by BindFlags keep every value, while the current
+		// value is used for any flag defined by the caller
+		values := []string{f.Value.String()}
+		if fv, ok := f.Value.(*flagValue); ok {
+			values = fv.values
+		}
+
+		applied[s.resolvePath(field)] = true
+		if err := s.setFieldValues(field, values, "Args"); err != nil {
+			errs = errs.append(withSource(err, SourceArgs, arg, strings.Join(values, " ")))
+			continue
+		}
+
+		s.track(field, SourceArgs, arg)
+	}
+
+	// global fields were set by the flags above, so only the arguments after
+	// the subcommand remain to be read (i.e. svc -v serve --port 80)
+	s.selectCommand(fs.Args(), a, false)
+	s.globalArgs = nil
+
+	return errs.append(s.readArgs(a)).err()
+}
@@ -0,0 +1,185 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testFlagsConfig struct {
+	Name    string        `yaml:"name" arg:"--name" desc:"service name"`
+	Port    int           `yaml:"port" arg:"--port" default:"8080"`
+	Tags    []string      `arg:"--tag"`
+	Timeout time.Duration `yaml:"timeout" arg:"--timeout"`
+	Verbose bool          `arg:"-v" desc:"verbose output"`
+}
+
+func TestBindFlags(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("name: base\nport: 80\ntimeout: 5s\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	opts := Options().
+		SetBasePath(basePath).
+		SetArg("--verbose", "Verbose")
+
+	t.Run("should apply only explicitly set flags", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		debug := fs.Bool("debug", false, "defined by the caller")
+
+		if err := BindFlags(fs, &testFlagsConfig{}, opts); err != nil {
+			t.Fatalf("BindFlags() unexpected error = %v", err)
+		}
+
+		if err := fs.Parse([]string{"-debug", "--port", "9090", "-tag", "a,b", "-tag", "c", "-v", "positional"}); err != nil {
+			t.Fatalf("FlagSet.Parse() unexpected error = %v", err)
+		}
+
+		cfg := &testFlagsConfig{}
+		r, err := GatherWithReport(opts.SetFlagSet(fs), cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		want := &testFlagsConfig{
+			Name:    "base",
+			Port:    9090,
+			Tags:    []string{"a", "b", "c"},
+			Timeout: 5 * time.Second,
+			Verbose: true,
+		}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Gather() = %+v, want %+v", cfg, want)
+		}
+
+		if !*debug || fs.Arg(0) != "positional" {
+			t.Errorf("FlagSet.Parse() debug = %v, args = %v", *debug, fs.Args())
+		}
+
+		if fr, _ := r.Field("Verbose"); fr.Source != SourceArgs || fr.Origin != "-v" {
+			t.Errorf("Report.Field(Verbose) = %+v", fr)
+		}
+	})
+
+	t.Run("should read flags defined by the caller", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Duration("timeout", time.Second, "defined by the caller")
+
+		if err := BindFlags(fs, &testFlagsConfig{}, opts); err != nil {
+			t.Fatalf("BindFlags() unexpected error = %v", err)
+		}
+
+		if err := fs.Parse([]string{"-timeout", "1m30s"}); err != nil {
+			t.Fatalf("FlagSet.Parse() unexpected error = %v", err)
+		}
+
+		cfg, err := Load[testFlagsConfig](opts.SetFlagSet(fs))
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+
+		if cfg.Timeout != 90*time.Second || cfg.Port != 80 {
+			t.Errorf("Load() = %+v", cfg)
+		}
+	})
+
+	t.Run("should register usage and defaults", func(t *testing.T) {
+		var out bytes.Buffer
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(&out)
+
+		if err := BindFlags(fs, &testFlagsConfig{}, opts); err != nil {
+			t.Fatalf("BindFlags() unexpected error = %v", err)
+		}
+
+		if f := fs.Lookup("port"); f == nil || f.DefValue != "8080" {
+			t.Errorf("FlagSet.Lookup(port) = %+v", f)
+		}
+
+		fs.PrintDefaults()
+		for _, want := range []string{
+			"-name string\n    \tservice name (string)",
+			"-port int\n    \tPort (int) (default 8080)",
+			"-tag []string\n    \tTags ([]string)",
+			"-timeout time.Duration",
+			"-v\tverbose output",
+		} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("FlagSet.PrintDefaults() = %q, missing %q", out.String(), want)
+			}
+		}
+	})
+
+	t.Run("should reject invalid values when parsing", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+
+		if err := BindFlags(fs, &testFlagsConfig{}, opts); err != nil {
+			t.Fatalf("BindFlags() unexpected error = %v", err)
+		}
+
+		if err := fs.Parse([]string{"--port", "eighty"}); err == nil || !strings.Contains(err.Error(), "invalid syntax") {
+			t.Errorf("FlagSet.Parse() expected invalid syntax error, got %v", err)
+		}
+	})
+
+	t.Run("should error for mappings to missing fields", func(t *testing.T) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		err := BindFlags(fs, &testFlagsConfig{}, Options().SetArg("--missing", "Missing"))
+		if !errors.Is(err, ErrFieldDoesNotExist) {
+			t.Errorf("BindFlags() expected field does not exist error, got %v", err)
+		}
+	})
+}
+
+func TestBindFlags_Commands(t *testing.T) {
+	t.Parallel()
+
+	opts := Options().SetEnv(map[string]string{})
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := BindFlags(fs, &testCLIConfig{}, opts); err != nil {
+		t.Fatalf("BindFlags() unexpected error = %v", err)
+	}
+
+	// arguments for a subcommand are read after it rather than registered
+	if f := fs.Lookup("cert"); f != nil {
+		t.Errorf("FlagSet.Lookup(cert) = %+v, want nil", f)
+	}
+
+	if err := fs.Parse([]string{"-v", "--port", "80", "serve", "--port", "8443", "--cert", "server.pem"}); err != nil {
+		t.Fatalf("FlagSet.Parse() unexpected error = %v", err)
+	}
+
+	cfg := &testCLIConfig{}
+	r, err := GatherWithReport(opts.SetFlagSet(fs), cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	want := &testCLIConfig{Port: 80, Verbose: true}
+	want.Serve.Port = 8443
+	want.Serve.TLS.Cert = "server.pem"
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Gather() = %+v, want %+v", cfg, want)
+	}
+
+	if r.Command != "serve" {
+		t.Errorf("Report.Command = %q, want serve", r.Command)
+	}
+
+	if fr, _ := r.Field("Serve.Port"); fr.Source != SourceArgs || fr.Origin != "--port" {
+		t.Errorf("Report.Field(Serve.Port) = %+v", fr)
+	}
+}
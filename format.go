@@ -0,0 +1,126 @@
+package settings
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+type format struct {
+	name   string
+	exts   []string
+	decode func([]byte, any) error
+}
+
+var (
+	formats   = []format{}
+	formatsMu sync.RWMutex
+)
+
+func init() {
+	RegisterFormat("yaml", []string{".yml", ".yaml"}, yaml.Unmarshal)
+	RegisterFormat("json", []string{".json"}, json.Unmarshal)
+	RegisterFormat("toml", []string{".toml"}, toml.Unmarshal)
+}
+
+// RegisterFormat adds support for settings files with the specified extensions,
+// decoded with the provided func. Registered formats are used for the base file,
+// command line override files and environment override file discovery (in the
+// order formats are registered). The name of the format is also the struct tag
+// used to map keys within the file to fields (i.e. `ini:"name"`). Registering an
+// existing name replaces the format, and registering an existing extension moves
+// the extension to the new format. Files are decoded into the out struct, and
+// then into an *any to report which fields each file set; when the decoder
+// rejects an *any, the file is still applied but its fields are not reported.
+func RegisterFormat(name string, exts []string, decode func([]byte, any) error) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	f := format{
+		name:   name,
+		decode: decode,
+	}
+
+	for _, ext := range exts {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		f.exts = append(f.exts, ext)
+	}
+
+	// remove the extensions from any previously registered format
+	for i, rf := range formats {
+		kept := []string{}
+		for _, ext := range rf.exts {
+			if !contains(f.exts, ext) {
+				kept = append(kept, ext)
+			}
+		}
+		formats[i].exts = kept
+	}
+
+	// replace a format with the same name in place
+	for i, rf := range formats {
+		if rf.name == name {
+			formats[i] = f
+			return
+		}
+	}
+
+	formats = append(formats, f)
+}
+
+// formatExtensions returns every registered extension in registration order
+func formatExtensions() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	exts := []string{}
+	for _, f := range formats {
+		exts = append(exts, f.exts...)
+	}
+
+	return exts
+}
+
+// formatByExt finds the registered format for a file extension
+func formatByExt(ext string) (format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		if contains(f.exts, ext) {
+			return f, true
+		}
+	}
+
+	return format{}, false
+}
+
+// formatByName finds the registered format with the specified name
+func formatByName(name string) (format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		if f.name == name {
+			return f, true
+		}
+	}
+
+	return format{}, false
+}
+
+func contains(s []string, v string) bool {
+	for _, sv := range s {
+		if sv == v {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,160 @@
+package settings
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decodeINI is a deliberately simple key=value decoder used to exercise RegisterFormat
+func decodeINI(in []byte, out any) error {
+	m := map[string]any{}
+	sc := bufio.NewScanner(bytes.NewReader(in))
+	for sc.Scan() {
+		if k, v, ok := strings.Cut(sc.Text(), "="); ok {
+			m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+func restoreFormats(t *testing.T) {
+	formatsMu.Lock()
+	orig := make([]format, len(formats))
+	copy(orig, formats)
+	formatsMu.Unlock()
+
+	t.Cleanup(func() {
+		formatsMu.Lock()
+		formats = orig
+		formatsMu.Unlock()
+	})
+}
+
+func TestRegisterFormat(t *testing.T) {
+	restoreFormats(t)
+
+	RegisterFormat("ini", []string{"ini", ".cfg"}, decodeINI)
+
+	if f, ok := formatByExt(".ini"); !ok || f.name != "ini" {
+		t.Fatalf("formatByExt(.ini) = %v, %v", f.name, ok)
+	}
+	if exts := formatExtensions(); !reflect.DeepEqual(exts, []string{".yml", ".yaml", ".json", ".toml", ".ini", ".cfg"}) {
+		t.Fatalf("formatExtensions() = %v", exts)
+	}
+
+	// extensions move to the most recent registration
+	RegisterFormat("conf", []string{".cfg"}, decodeINI)
+	if f, _ := formatByExt(".cfg"); f.name != "conf" {
+		t.Fatalf("formatByExt(.cfg) = %v, want conf", f.name)
+	}
+	if f, _ := formatByName("ini"); !reflect.DeepEqual(f.exts, []string{".ini"}) {
+		t.Fatalf("formatByName(ini).exts = %v, want [.ini]", f.exts)
+	}
+
+	// names are replaced in place
+	RegisterFormat("ini", []string{".ini"}, json.Unmarshal)
+	if exts := formatExtensions(); !reflect.DeepEqual(exts, []string{".yml", ".yaml", ".json", ".toml", ".ini", ".cfg"}) {
+		t.Fatalf("formatExtensions() after replace = %v", exts)
+	}
+}
+
+func TestGather_RegisteredFormat(t *testing.T) {
+	restoreFormats(t)
+	RegisterFormat("ini", []string{".ini"}, decodeINI)
+
+	type testConfig struct {
+		Name    string `ini:"name"`
+		Version string `ini:"version"`
+		Host    string
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.ini":     "name = base\nversion = 1.0\nhost = localhost\n",
+		"override.ini": "version = 2.0\n",
+		"staging.ini":  "host = staging.internal\n",
+		"broken.txt":   "name = broken\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+
+	opts := Options().
+		SetArgs([]string{"cmd", "--config-file", filepath.Join(dir, "override.ini")}).
+		SetEnv(map[string]string{"GO_ENV": "staging"}).
+		SetBasePath(filepath.Join(dir, "base.ini")).
+		SetArgsFileOverride("--config-file").
+		SetEnvOverride("GO_ENV").
+		SetEnvSearchPaths(dir)
+
+	cfg := &testConfig{}
+	r, err := GatherWithReport(opts, cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	want := &testConfig{Name: "base", Version: "2.0", Host: "staging.internal"}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Gather() = %+v, want %+v", cfg, want)
+	}
+
+	if fr, _ := r.Field("Host"); fr.Source != SourceEnvFile {
+		t.Errorf("Report.Field(Host) = %+v", fr)
+	}
+
+	if err := Gather(Options().SetBasePath(filepath.Join(dir, "broken.txt")), &testConfig{}); err == nil {
+		t.Errorf("Gather() expected error for unregistered extension")
+	}
+}
+
+func TestGather_StructOnlyFormat(t *testing.T) {
+	restoreFormats(t)
+
+	// decoders such as ini MapTo only accept a pointer to a struct
+	RegisterFormat("ini", []string{".ini"}, func(in []byte, out any) error {
+		if v := reflect.ValueOf(out); v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return errors.New("target must be a struct pointer")
+		}
+
+		return decodeINI(in, out)
+	})
+
+	type testConfig struct {
+		Name string `ini:"name"`
+	}
+
+	basePath := filepath.Join(t.TempDir(), "base.ini")
+	if err := os.WriteFile(basePath, []byte("name = base\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	cfg := &testConfig{}
+	r, err := GatherWithReport(Options().SetBasePath(basePath).SetArgs([]string{}).SetEnv(map[string]string{}), cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	if cfg.Name != "base" {
+		t.Errorf("Gather() Name = %s, want base", cfg.Name)
+	}
+
+	// the fields set by the file are not reported
+	if fr, _ := r.Field("Name"); fr.IsSet() {
+		t.Errorf("Report.Field(Name) = %+v, want not set", fr)
+	}
+}
@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// OSFS returns a filesystem that reads settings files from disk using paths
+// exactly as they are provided (relative to the working directory, or absolute),
+// for use alongside other filesystems provided to ReadOptions.SetFS
+func OSFS() fs.FS {
+	return osFS{}
+}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// filesystems returns each filesystem settings files are read from, in order
+func (s *settings) filesystems() []fs.FS {
+	if len(s.fsys) == 0 {
+		return []fs.FS{osFS{}}
+	}
+
+	return s.fsys
+}
+
+// fsPath converts a settings file path into a valid path for the filesystem
+// (i.e. "./config/base.yaml" is "config/base.yaml" within an embed.FS)
+func fsPath(fsys fs.FS, name string) string {
+	if _, ok := fsys.(osFS); ok {
+		return name
+	}
+
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+// statFile returns each filesystem (in order) that the settings file exists in,
+// or a SettingsFileNotFound error when it does not exist in any of them
+func (s *settings) statFile(name string) ([]fs.FS, error) {
+	found := []fs.FS{}
+	var notExist error
+
+	// every file considered is watched for changes by Watch
+	s.watched = append(s.watched, name)
+
+	for _, fsys := range s.filesystems() {
+		if _, err := fs.Stat(fsys, fsPath(fsys, name)); err != nil {
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+				if notExist == nil {
+					notExist = err
+				}
+
+				continue
+			}
+
+			// unable to stat the file for other reasons...
+			return nil, fileReadError(name, err)
+		}
+
+		found = append(found, fsys)
+	}
+
+	if len(found) == 0 {
+		return nil, SettingsFileNotFound(name, notExist)
+	}
+
+	return found, nil
+}
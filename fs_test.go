@@ -0,0 +1,119 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGather_FS(t *testing.T) {
+	type testConfig struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+		Host    string `yaml:"host"`
+		Port    int    `yaml:"port"`
+	}
+
+	embedded := fstest.MapFS{
+		"config/base.yaml":       {Data: []byte("name: embedded\nversion: \"1.0\"\nhost: localhost\nport: 8080\n")},
+		"config/production.yaml": {Data: []byte("host: prod.internal\n")},
+	}
+
+	env := map[string]string{"GO_ENV": "production"}
+
+	t.Run("should read base and environment files from the filesystem", func(t *testing.T) {
+		opts := Options().
+			SetFS(embedded).
+			SetBasePath("./config/base.yaml").
+			SetArgs([]string{}).
+			SetEnv(env).
+			SetEnvOverride("GO_ENV").
+			SetEnvSearchPaths("./config")
+
+		cfg := &testConfig{}
+		if err := Gather(opts, cfg); err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		want := &testConfig{Name: "embedded", Version: "1.0", Host: "prod.internal", Port: 8080}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Gather() = %+v, want %+v", cfg, want)
+		}
+	})
+
+	t.Run("should layer files on disk over embedded files", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "config"), 0o755); err != nil {
+			t.Fatalf("unable to create config dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config", "base.yaml"), []byte("version: \"2.0\"\n"), 0o600); err != nil {
+			t.Fatalf("unable to write base file: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "config", "production.yaml"), []byte("port: 443\n"), 0o600); err != nil {
+			t.Fatalf("unable to write env file: %v", err)
+		}
+
+		origDir, _ := os.Getwd()
+		if err := os.Chdir(dir); err != nil {
+			t.Fatalf("unable to change directory: %v", err)
+		}
+		defer os.Chdir(origDir)
+
+		opts := Options().
+			SetFS(embedded, OSFS()).
+			SetBasePath("./config/base.yaml").
+			SetArgs([]string{}).
+			SetEnv(env).
+			SetEnvOverride("GO_ENV").
+			SetEnvSearchPaths("./config")
+
+		cfg := &testConfig{}
+		r, err := GatherWithReport(opts, cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		want := &testConfig{Name: "embedded", Version: "2.0", Host: "prod.internal", Port: 443}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Gather() = %+v, want %+v", cfg, want)
+		}
+
+		if fr, _ := r.Field("Version"); fr.Source != SourceBaseFile || len(fr.Overridden) != 1 {
+			t.Errorf("Report.Field(Version) = %+v", fr)
+		}
+	})
+
+	t.Run("should error when the base file is in none of the filesystems", func(t *testing.T) {
+		err := Gather(Options().SetFS(embedded).SetBasePath("./tests/simple.yaml"), &testConfig{})
+		if !errors.Is(err, ErrFileNotFound) || !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("Gather() expected ErrFileNotFound, got %v", err)
+		}
+	})
+}
+
+func Test_fsPath(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"should strip leading ./", "./config/base.yaml", "config/base.yaml"},
+		{"should strip leading /", "/config/base.yaml", "config/base.yaml"},
+		{"should clean the path", "config/../config/./base.yaml", "config/base.yaml"},
+		{"should use . for the current directory", "./", "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fsPath(fstest.MapFS{}, tt.in); got != tt.want {
+				t.Errorf("fsPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := fsPath(OSFS(), "./config/base.yaml"); got != "./config/base.yaml" {
+		t.Errorf("fsPath() for OSFS = %v, want path unchanged", got)
+	}
+}
@@ -2,4 +2,7 @@ module go.jtlabs.io/settings
 
 go 1.24
 
-require gopkg.in/yaml.v2 v2.4.0
+require (
+	github.com/BurntSushi/toml v1.4.0
+	gopkg.in/yaml.v2 v2.4.0
+)
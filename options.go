@@ -1,17 +1,53 @@
 package settings
 
+import (
+	"flag"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// EmptyPolicy determines how environment variables and command line arguments
+// that are present, but set to an empty value, are applied
+type EmptyPolicy int
+
+const (
+	// EmptyIgnore treats empty environment variables as though they were not
+	// set (the default); empty command line argument values are converted as
+	// any other value would be
+	EmptyIgnore EmptyPolicy = iota
+
+	// EmptyClear sets the field to its zero value for empty environment
+	// variables and command line argument values, replacing any value from
+	// an earlier source
+	EmptyClear
+)
+
 // ReadOptions define additional optional instructions for
 // the Settings package when reading and compiling layers of
 // configuration settings from various sources
 type ReadOptions struct {
-	ArgsFileOverride []string
-	ArgsMap          map[string]string
-	BasePath         string
-	DefaultsMap      map[string]interface{}
-	EnvOverride      []string
-	EnvSearchPaths   []string
-	EnvSearchPattern string
-	VarsMap          map[string]string
+	AppendSlices      bool
+	Args              []string
+	ArgsFileOverride  []string
+	ArgsMap           map[string]string
+	BasePath          string
+	DefaultsMap       map[string]interface{}
+	DotenvFiles       []string
+	EmptyValues       EmptyPolicy
+	EnvOverride       []string
+	EnvSearchPaths    []string
+	EnvSearchPattern  string
+	Environ           func() []string
+	FS                []fs.FS
+	FlagSet           *flag.FlagSet
+	HelpOutput        io.Writer
+	ListSeparator     string
+	LookupEnv         func(string) (string, bool)
+	Required          []string
+	VarsMap           map[string]string
+	WatchErrorHandler func(error)
+	WatchInterval     time.Duration
 }
 
 // Options returns an empty ReadOptions for use with the
@@ -29,6 +65,14 @@ func (ro ReadOptions) EnvDefault() ReadOptions {
 		SetEnvSearchPaths("./", "./config", "./settings")
 }
 
+// SetAppendSlices controls whether slice values from default tags, command
+// line arguments and environment variables are added to the end of the slice
+// already gathered (i.e. from settings files) rather than replacing it
+func (ro ReadOptions) SetAppendSlices(appendSlices bool) ReadOptions {
+	ro.AppendSlices = appendSlices
+	return ro
+}
+
 // SetArg can be used to explicitly map a command line argument to a field
 func (ro ReadOptions) SetArg(arg string, fieldPath string) ReadOptions {
 	// ensure it's not empty
@@ -40,6 +84,18 @@ func (ro ReadOptions) SetArg(arg string, fieldPath string) ReadOptions {
 	return ro
 }
 
+// SetArgs provides the command line arguments to read switches and override
+// file locations from, in place of os.Args (i.e. for hermetic tests); as with
+// os.Args, the first argument is the program name and is never a subcommand
+func (ro ReadOptions) SetArgs(args []string) ReadOptions {
+	if args == nil {
+		args = []string{}
+	}
+
+	ro.Args = args
+	return ro
+}
+
 // SetArgsFileOverride instructs the settings package on where to look
 // for any potential override file locations that are provided as command
 // line arguments
@@ -106,6 +162,58 @@ func (ro ReadOptions) SetDefaultsMap(defMap map[string]interface{}, rewrite ...b
 	return ro
 }
 
+// SetDotenvFiles instructs the settings package to read variables from one
+// or more dotenv (.env) files, in order, for use alongside environment
+// variables (real environment variables take precedence over dotenv values)
+func (ro ReadOptions) SetDotenvFiles(paths ...string) ReadOptions {
+	if len(ro.DotenvFiles) == 0 {
+		ro.DotenvFiles = []string{}
+	}
+
+	ro.DotenvFiles = append(ro.DotenvFiles, paths...)
+
+	return ro
+}
+
+// SetEmptyValues sets the policy for environment variables and command line
+// arguments that are present but empty (EmptyIgnore by default)
+func (ro ReadOptions) SetEmptyValues(policy EmptyPolicy) ReadOptions {
+	ro.EmptyValues = policy
+	return ro
+}
+
+// SetEnv provides the environment variables to read values and environment
+// override names from, in place of the process environment
+func (ro ReadOptions) SetEnv(env map[string]string) ReadOptions {
+	vars := map[string]string{}
+	for k, v := range env {
+		vars[k] = v
+	}
+
+	return ro.
+		SetLookupEnv(func(name string) (string, bool) {
+			v, ok := vars[name]
+			return v, ok
+		}).
+		SetEnviron(func() []string {
+			environ := []string{}
+			for k, v := range vars {
+				environ = append(environ, k+"="+v)
+			}
+
+			return environ
+		})
+}
+
+// SetEnviron provides the func used to list environment variables (in the
+// same KEY=value form as os.Environ) when looking for variables that set
+// entries within map fields, in place of os.Environ; when SetLookupEnv is
+// used without SetEnviron, only dotenv values are listed
+func (ro ReadOptions) SetEnviron(environ func() []string) ReadOptions {
+	ro.Environ = environ
+	return ro
+}
+
 // SetEnvOverride instructs the settings package on where to look
 // for any potential override file locations that are provided as environment
 // variables to the application
@@ -140,6 +248,59 @@ func (ro ReadOptions) SetEnvSearchPattern(pattern string) ReadOptions {
 	return ro
 }
 
+// SetFS instructs the settings package to read settings files (the base file,
+// override files and dotenv files) from the provided filesystems instead of
+// from disk (i.e. from an embed.FS). When more than one filesystem is provided,
+// a file found in several of them is read from each in order, so that an
+// embedded file can be overridden by the same file on disk:
+//
+//	SetFS(embeddedConfig, settings.OSFS())
+func (ro ReadOptions) SetFS(fsys ...fs.FS) ReadOptions {
+	if len(ro.FS) == 0 {
+		ro.FS = []fs.FS{}
+	}
+
+	ro.FS = append(ro.FS, fsys...)
+
+	return ro
+}
+
+// SetFlagSet provides a parsed flag.FlagSet (see BindFlags) for command line
+// arguments; only flags that were explicitly set are applied, in place of
+// scanning the arguments for each mapped switch
+func (ro ReadOptions) SetFlagSet(fs *flag.FlagSet) ReadOptions {
+	ro.FlagSet = fs
+	return ro
+}
+
+// SetHelpOutput enables -h and --help: when either is provided, Gather
+// writes the Usage for the out struct to w and returns ErrHelp
+func (ro ReadOptions) SetHelpOutput(w io.Writer) ReadOptions {
+	ro.HelpOutput = w
+	return ro
+}
+
+// SetListSeparator sets the separator between the elements of slice and map
+// values (a comma by default); a sep tag on the field takes precedence
+func (ro ReadOptions) SetListSeparator(sep string) ReadOptions {
+	ro.ListSeparator = sep
+	return ro
+}
+
+// SetLookupEnv provides the func used to look up environment variables, in
+// place of os.LookupEnv
+func (ro ReadOptions) SetLookupEnv(lookup func(string) (string, bool)) ReadOptions {
+	ro.LookupEnv = lookup
+	return ro
+}
+
+// SetRequired marks fields (by dotted path) that must be set by at least one
+// source, otherwise Gather returns a SettingsFieldRequired error for each
+func (ro ReadOptions) SetRequired(fieldPaths ...string) ReadOptions {
+	ro.Required = append(ro.Required, fieldPaths...)
+	return ro
+}
+
 // SetVar can be used to explicitly map an environment variable to a field
 func (ro ReadOptions) SetVar(v string, fieldPath string) ReadOptions {
 	// ensure it's not empty
@@ -173,6 +334,21 @@ func (ro ReadOptions) SetVarsMap(varsMap map[string]string, rewrite ...bool) Rea
 	return ro
 }
 
+// SetWatchErrorHandler provides a func that is called by Watch and
+// Store.ReloadOnSignal with any error that occurs while gathering settings
+// again after a file has changed or a signal was received
+func (ro ReadOptions) SetWatchErrorHandler(fn func(error)) ReadOptions {
+	ro.WatchErrorHandler = fn
+	return ro
+}
+
+// SetWatchInterval determines how often Watch checks settings files for
+// changes (defaults to 1 second)
+func (ro ReadOptions) SetWatchInterval(d time.Duration) ReadOptions {
+	ro.WatchInterval = d
+	return ro
+}
+
 func populateMap(tm *map[string]string, fm map[string]string) {
 	for k, v := range fm {
 		(*tm)[k] = v
@@ -1,8 +1,13 @@
 package settings
 
 import (
+	"bytes"
+	"flag"
+	"io/fs"
 	"reflect"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -73,6 +78,12 @@ func TestReadOptions_EnvDefault(t *testing.T) {
 	}
 }
 
+func TestReadOptions_SetAppendSlices(t *testing.T) {
+	if got := Options().SetAppendSlices(true); !got.AppendSlices {
+		t.Errorf("ReadOptions.SetAppendSlices() = %v, want true", got.AppendSlices)
+	}
+}
+
 func TestReadOptions_SetArg(t *testing.T) {
 	type args struct {
 		arg       string
@@ -129,6 +140,21 @@ func TestReadOptions_SetArg(t *testing.T) {
 	}
 }
 
+func TestReadOptions_SetArgs(t *testing.T) {
+	got := Options().SetArgs([]string{"cmd", "--port", "3000"})
+	want := ReadOptions{
+		Args: []string{"cmd", "--port", "3000"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadOptions.SetArgs() = %v, want %v", got, want)
+	}
+
+	// nil args are treated as no args rather than falling back to os.Args
+	if got := Options().SetArgs(nil); got.Args == nil || len(got.Args) != 0 {
+		t.Errorf("ReadOptions.SetArgs(nil) = %v, want empty args", got.Args)
+	}
+}
+
 func TestReadOptions_SetArgsFileOverride(t *testing.T) {
 	type args struct {
 		args []string
@@ -413,6 +439,85 @@ func TestReadOptions_SetDefaultsMap(t *testing.T) {
 	}
 }
 
+func TestReadOptions_SetDotenvFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		init  []string
+		paths []string
+		want  ReadOptions
+	}{
+		{
+			"should properly set the dotenv files when provided",
+			nil,
+			[]string{"./.env", "./.env.local"},
+			ReadOptions{
+				DotenvFiles: []string{"./.env", "./.env.local"},
+			},
+		},
+		{
+			"should append to existing dotenv files",
+			[]string{"./.env"},
+			[]string{"./.env.local"},
+			ReadOptions{
+				DotenvFiles: []string{"./.env", "./.env.local"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ro := Options()
+			ro.DotenvFiles = tt.init
+
+			if got := ro.SetDotenvFiles(tt.paths...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadOptions.SetDotenvFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOptions_SetFS(t *testing.T) {
+	embedded := fstest.MapFS{}
+	disk := OSFS()
+
+	got := Options().SetFS(embedded).SetFS(disk)
+	want := ReadOptions{
+		FS: []fs.FS{embedded, disk},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadOptions.SetFS() = %v, want %v", got, want)
+	}
+}
+
+func TestReadOptions_SetFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if got := Options().SetFlagSet(fs); got.FlagSet != fs {
+		t.Errorf("ReadOptions.SetFlagSet() = %v, want %v", got.FlagSet, fs)
+	}
+}
+
+func TestReadOptions_SetHelpOutput(t *testing.T) {
+	var b bytes.Buffer
+	if got := Options().SetHelpOutput(&b); got.HelpOutput != &b {
+		t.Errorf("ReadOptions.SetHelpOutput() = %v, want %v", got.HelpOutput, &b)
+	}
+}
+
+func TestReadOptions_SetListSeparator(t *testing.T) {
+	if got := Options().SetListSeparator(";"); got.ListSeparator != ";" {
+		t.Errorf("ReadOptions.SetListSeparator() = %q, want %q", got.ListSeparator, ";")
+	}
+}
+
+func TestReadOptions_SetRequired(t *testing.T) {
+	got := Options().SetRequired("Data.Host").SetRequired("Data.Port", "Name")
+	want := ReadOptions{
+		Required: []string{"Data.Host", "Data.Port", "Name"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadOptions.SetRequired() = %v, want %v", got, want)
+	}
+}
+
 func TestReadOptions_SetSearchPaths(t *testing.T) {
 	type args struct {
 		paths []string
@@ -443,6 +548,58 @@ func TestReadOptions_SetSearchPaths(t *testing.T) {
 	}
 }
 
+func TestReadOptions_SetEmptyValues(t *testing.T) {
+	if got := Options().EmptyValues; got != EmptyIgnore {
+		t.Errorf("ReadOptions.EmptyValues = %v, want EmptyIgnore", got)
+	}
+
+	if got := Options().SetEmptyValues(EmptyClear); got.EmptyValues != EmptyClear {
+		t.Errorf("ReadOptions.SetEmptyValues() = %v, want EmptyClear", got.EmptyValues)
+	}
+}
+
+func TestReadOptions_SetEnv(t *testing.T) {
+	env := map[string]string{"GO_ENV": "test"}
+	ro := Options().SetEnv(env)
+
+	// changes to the map after the call are not visible
+	env["GO_ENV"] = "changed"
+
+	if v, ok := ro.LookupEnv("GO_ENV"); !ok || v != "test" {
+		t.Errorf("ReadOptions.SetEnv() LookupEnv(GO_ENV) = %q, %v, want test, true", v, ok)
+	}
+
+	if _, ok := ro.LookupEnv("MISSING"); ok {
+		t.Errorf("ReadOptions.SetEnv() LookupEnv(MISSING) should not be found")
+	}
+}
+
+func TestReadOptions_SetEnviron(t *testing.T) {
+	ro := Options().SetEnviron(func() []string {
+		return []string{"GO_ENV=test"}
+	})
+
+	if got := ro.Environ(); !reflect.DeepEqual(got, []string{"GO_ENV=test"}) {
+		t.Errorf("ReadOptions.SetEnviron() Environ() = %v", got)
+	}
+
+	// SetEnv lists the provided variables
+	ro = Options().SetEnv(map[string]string{"GO_ENV": "test"})
+	if got := ro.Environ(); !reflect.DeepEqual(got, []string{"GO_ENV=test"}) {
+		t.Errorf("ReadOptions.SetEnv() Environ() = %v", got)
+	}
+}
+
+func TestReadOptions_SetLookupEnv(t *testing.T) {
+	ro := Options().SetLookupEnv(func(name string) (string, bool) {
+		return "value of " + name, true
+	})
+
+	if v, _ := ro.LookupEnv("HOME"); v != "value of HOME" {
+		t.Errorf("ReadOptions.SetLookupEnv() LookupEnv(HOME) = %q", v)
+	}
+}
+
 func TestReadOptions_SetEnvSearchPattern(t *testing.T) {
 	type args struct {
 		pattern string
@@ -670,3 +827,17 @@ func TestReadOptions_SetVarsMap(t *testing.T) {
 		})
 	}
 }
+
+func TestReadOptions_SetWatchInterval(t *testing.T) {
+	got := Options().SetWatchInterval(5 * time.Second)
+	if got.WatchInterval != 5*time.Second {
+		t.Errorf("ReadOptions.SetWatchInterval() = %v, want 5s", got.WatchInterval)
+	}
+
+	called := false
+	got = got.SetWatchErrorHandler(func(error) { called = true })
+	got.WatchErrorHandler(nil)
+	if !called {
+		t.Errorf("ReadOptions.SetWatchErrorHandler() handler was not set")
+	}
+}
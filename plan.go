@@ -0,0 +1,134 @@
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// fieldPlan is the information discovered via reflection about an out struct
+// type; plans are cached per type and shared, so they must not be modified
+type fieldPlan struct {
+	aliases      map[string]string
+	argsMap      map[string]string
+	commands     map[string]string
+	defaults     map[string]string
+	descriptions map[string]string
+	fieldTypeMap map[string]reflect.Type
+	fields       []string
+	layouts      map[string]string
+	required     []string
+	separators   map[string]string
+	validate     map[string]string
+	varsMap      map[string]string
+}
+
+// fieldPlans caches the fieldPlan for each out struct type (map[reflect.Type]*fieldPlan)
+var fieldPlans sync.Map
+
+// planFor returns the cached fieldPlan for the struct type, creating it
+// when the type has not been seen before
+func planFor(t reflect.Type) *fieldPlan {
+	if p, ok := fieldPlans.Load(t); ok {
+		return p.(*fieldPlan)
+	}
+
+	s := settings{fieldTypeMap: map[string]reflect.Type{}}
+	var opts ReadOptions
+
+	// map targets have no fields until entries are known
+	if t.Kind() == reflect.Struct {
+		fields := t.NumField()
+		for i := 0; i < fields; i++ {
+			s.iterateFields("", t.Field(i))
+		}
+
+		// collect the arg, cmd, default, desc, env, layout, required, sep and validate tags on the struct
+		s.reflectTagOverrideArgs(t, &opts)
+	}
+
+	p, _ := fieldPlans.LoadOrStore(t, &fieldPlan{
+		aliases:      promotedAliases(t, s.fieldTypeMap),
+		argsMap:      opts.ArgsMap,
+		commands:     s.cmdTags,
+		defaults:     s.defaultTags,
+		descriptions: s.descTags,
+		fieldTypeMap: s.fieldTypeMap,
+		fields:       s.fieldOrder,
+		layouts:      s.layoutTags,
+		required:     s.requiredTags,
+		separators:   s.sepTags,
+		validate:     s.validateTags,
+		varsMap:      opts.VarsMap,
+	})
+
+	return p.(*fieldPlan)
+}
+
+// promotedAliases maps the promoted path of each field within an embedded
+// struct (i.e. "Port") to the full path (i.e. "Common.Port"); promoted paths
+// that conflict with another field, or that are promoted from more than one
+// embedded struct, are left out
+func promotedAliases(t reflect.Type, fieldTypeMap map[string]reflect.Type) map[string]string {
+	aliases := map[string]string{}
+	ambiguous := map[string]bool{}
+
+	for fieldPath := range fieldTypeMap {
+		alias := promotedPath(t, fieldPath)
+		if alias == fieldPath || alias == "" || ambiguous[alias] {
+			continue
+		}
+
+		if _, ok := fieldTypeMap[alias]; ok {
+			continue
+		}
+
+		if _, ok := aliases[alias]; ok {
+			delete(aliases, alias)
+			ambiguous[alias] = true
+			continue
+		}
+
+		aliases[alias] = fieldPath
+	}
+
+	return aliases
+}
+
+// promotedPath removes each embedded struct from the field path
+func promotedPath(t reflect.Type, fieldPath string) string {
+	names := []string{}
+
+	for _, name := range strings.Split(fieldPath, ".") {
+		f, ok := derefType(t).FieldByName(name)
+		if !ok {
+			return fieldPath
+		}
+
+		if !f.Anonymous || isLeaf(f.Type) {
+			names = append(names, name)
+		}
+
+		t = f.Type
+	}
+
+	return strings.Join(names, ".")
+}
+
+// applyTags adds the tag derived arg and env mappings to copies of the
+// ArgsMap and VarsMap (tags replace explicit mappings for the same name)
+func (p *fieldPlan) applyTags(opts *ReadOptions) {
+	if len(p.argsMap) > 0 {
+		argsMap := map[string]string{}
+		populateMap(&argsMap, opts.ArgsMap)
+		populateMap(&argsMap, p.argsMap)
+		opts.ArgsMap = argsMap
+	}
+
+	if len(p.varsMap) > 0 {
+		varsMap := map[string]string{}
+		populateMap(&varsMap, opts.VarsMap)
+		populateMap(&varsMap, p.varsMap)
+		opts.VarsMap = varsMap
+	}
+}
@@ -0,0 +1,50 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_planFor(t *testing.T) {
+	type nested struct {
+		Level string `env:"LOG_LEVEL"`
+	}
+
+	type testConfig struct {
+		Name string `arg:"--name"`
+		Log  nested
+	}
+
+	t.Parallel()
+
+	ct := reflect.TypeOf(testConfig{})
+	p := planFor(ct)
+
+	if p != planFor(ct) {
+		t.Errorf("planFor() should return the cached plan for the same type")
+	}
+
+	wantTypes := map[string]reflect.Type{
+		"Name":      reflect.TypeOf(""),
+		"Log.Level": reflect.TypeOf(""),
+	}
+	if !reflect.DeepEqual(p.fieldTypeMap, wantTypes) {
+		t.Errorf("planFor() fieldTypeMap = %v, want %v", p.fieldTypeMap, wantTypes)
+	}
+
+	// tag mappings are added to copies of the provided maps
+	argsMap := map[string]string{"--other": "Other"}
+	opts := Options().SetArgsMap(argsMap, true)
+	p.applyTags(&opts)
+
+	wantArgs := map[string]string{"--other": "Other", "--name": "Name"}
+	if !reflect.DeepEqual(opts.ArgsMap, wantArgs) {
+		t.Errorf("fieldPlan.applyTags() ArgsMap = %v, want %v", opts.ArgsMap, wantArgs)
+	}
+	if len(argsMap) != 1 {
+		t.Errorf("fieldPlan.applyTags() should not modify the provided ArgsMap, got %v", argsMap)
+	}
+	if !reflect.DeepEqual(opts.VarsMap, map[string]string{"LOG_LEVEL": "Log.Level"}) {
+		t.Errorf("fieldPlan.applyTags() VarsMap = %v", opts.VarsMap)
+	}
+}
@@ -0,0 +1,284 @@
+package settings
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Source identifies the layer that supplied the value for a field
+type Source string
+
+const (
+	// SourceNone indicates that no layer supplied a value for the field
+	SourceNone Source = ""
+	// SourceDefaultTag is the default struct tag of the field
+	SourceDefaultTag Source = "DefaultTag"
+	// SourceBaseFile is the file provided via ReadOptions.BasePath
+	SourceBaseFile Source = "BaseFile"
+	// SourceDefaultsMap is the map provided via ReadOptions.DefaultsMap
+	SourceDefaultsMap Source = "DefaultsMap"
+	// SourceArgsFile is an override file provided via a command line argument
+	SourceArgsFile Source = "ArgsFile"
+	// SourceEnvFile is an override file discovered via an environment variable
+	SourceEnvFile Source = "EnvFile"
+	// SourceArgs is a command line argument
+	SourceArgs Source = "Args"
+	// SourceVars is an environment variable
+	SourceVars Source = "Vars"
+	// SourceDotenv is a variable read from a dotenv file
+	SourceDotenv Source = "Dotenv"
+)
+
+// Assignment is a single value applied to a field by one of the layers
+type Assignment struct {
+	Source Source
+	Origin string
+	Value  interface{}
+}
+
+// FieldReport describes the final value of a field, where it came from
+// and every earlier value that was overridden along the way
+type FieldReport struct {
+	Path       string
+	Source     Source
+	Origin     string
+	Value      interface{}
+	Overridden []Assignment
+}
+
+// IsSet returns true when at least one layer supplied a value for the field
+func (fr FieldReport) IsSet() bool {
+	return fr.Source != SourceNone
+}
+
+// Report describes, per dotted field path, which layer supplied the
+// final value of each field in the out struct provided to GatherWithReport
+type Report struct {
+	// Command is the subcommand (the cmd tag of a nested struct) that was
+	// selected by the command line arguments, if any
+	Command string
+	Fields  map[string]*FieldReport
+
+	aliases map[string]string
+}
+
+// Field returns the report for the specified dotted field path (fields
+// promoted from embedded structs can be found by either path)
+func (r *Report) Field(fieldPath string) (FieldReport, bool) {
+	if r == nil {
+		return FieldReport{}, false
+	}
+
+	if p, ok := r.aliases[fieldPath]; ok {
+		fieldPath = p
+	}
+
+	fr, ok := r.Fields[fieldPath]
+	if !ok {
+		return FieldReport{}, false
+	}
+
+	return *fr, true
+}
+
+// String renders the report as one line per field, sorted by field path
+func (r *Report) String() string {
+	if r == nil {
+		return ""
+	}
+
+	paths := make([]string, 0, len(r.Fields))
+	for p := range r.Fields {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, p := range paths {
+		fr := r.Fields[p]
+		if !fr.IsSet() {
+			fmt.Fprintf(&b, "%s: (not set)\n", p)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s: %v (%s", p, fr.Value, fr.Source)
+		if fr.Origin != "" {
+			fmt.Fprintf(&b, " %s", fr.Origin)
+		}
+		b.WriteString(")")
+
+		for i := len(fr.Overridden) - 1; i >= 0; i-- {
+			a := fr.Overridden[i]
+			fmt.Fprintf(&b, " <- %v (%s", a.Value, a.Source)
+			if a.Origin != "" {
+				fmt.Fprintf(&b, " %s", a.Origin)
+			}
+			b.WriteString(")")
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func newReport(fieldTypeMap map[string]reflect.Type, aliases map[string]string) *Report {
+	r := &Report{
+		Fields:  map[string]*FieldReport{},
+		aliases: aliases,
+	}
+
+	for p := range fieldTypeMap {
+		r.Fields[p] = &FieldReport{Path: p}
+	}
+
+	return r
+}
+
+func (r *Report) assign(fieldPath string, src Source, origin string, val interface{}) {
+	fr, ok := r.Fields[fieldPath]
+	if !ok {
+		fr = &FieldReport{Path: fieldPath}
+		r.Fields[fieldPath] = fr
+	}
+
+	// keep the previous value in the chain of overridden values
+	if fr.IsSet() {
+		fr.Overridden = append(fr.Overridden, Assignment{
+			Source: fr.Source,
+			Origin: fr.Origin,
+			Value:  fr.Value,
+		})
+	}
+
+	fr.Source = src
+	fr.Origin = origin
+	fr.Value = val
+}
+
+// track records the current value of the field in the report (when one is
+// being compiled) as having been supplied by the specified source
+func (s *settings) track(fieldPath string, src Source, origin string) {
+	if s.report == nil {
+		return
+	}
+
+	fieldPath = s.resolvePath(fieldPath)
+
+	var val interface{}
+	if v := s.findOutFieldValue(fieldPath); v.IsValid() && v.CanInterface() {
+		val = v.Interface()
+	}
+
+	s.report.assign(fieldPath, src, origin, val)
+}
+
+// fileFieldPaths walks a generically decoded settings file alongside the
+// struct type it is unmarshalled into and collects the dotted field path
+// of every field the file supplies a value for
+func (s *settings) fileFieldPaths(doc interface{}, t reflect.Type, tagName string, prefix string, paths *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	dv := reflect.ValueOf(doc)
+	if t.Kind() != reflect.Struct || dv.Kind() != reflect.Map {
+		return
+	}
+
+	iter := dv.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key().Interface())
+
+		fldPath, fldType, ok := fileField(t, key, tagName)
+		if !ok {
+			continue
+		}
+
+		fldNm := fldPath
+		if prefix != "" {
+			fldNm = fmt.Sprintf("%s.%s", prefix, fldNm)
+		}
+
+		// recurse into nested structs
+		if _, leaf := s.fieldTypeMap[fldNm]; !leaf {
+			s.fileFieldPaths(iter.Value().Interface(), fldType, tagName, fldNm, paths)
+			continue
+		}
+
+		*paths = append(*paths, fldNm)
+	}
+}
+
+// fileField finds the struct field that a key within a settings file maps to
+// using the same naming rules as the decoder identified by tagName, returning
+// the path to the field relative to t (through any inlined embedded structs)
+func fileField(t reflect.Type, key string, tagName string) (string, reflect.Type, bool) {
+	embedded := []reflect.StructField{}
+	folded := -1
+
+	fields := t.NumField()
+	for i := 0; i < fields; i++ {
+		fld := t.Field(i)
+		tag := fld.Tag.Get(tagName)
+
+		// a tag of "-," names the key "-" rather than skipping the field
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		// embedded structs are inlined by json and toml (and by yaml when
+		// tagged with the inline flag), so their fields are checked last
+		if fld.Anonymous && name == "" && !isLeaf(fld.Type) &&
+			(tagName != "yaml" || strings.Contains(tag, ",inline")) {
+			embedded = append(embedded, fld)
+			continue
+		}
+
+		if !fld.IsExported() {
+			continue
+		}
+
+		// yaml keys match the tag or the lowercased field name exactly, while
+		// json and toml keys prefer an exact match to one regardless of case
+		if tagName == "yaml" {
+			if name == "" {
+				name = strings.ToLower(fld.Name)
+			}
+
+			if name == key {
+				return fld.Name, fld.Type, true
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = fld.Name
+		}
+
+		if name == key {
+			return fld.Name, fld.Type, true
+		}
+
+		if folded < 0 && strings.EqualFold(name, key) {
+			folded = i
+		}
+	}
+
+	if folded >= 0 {
+		fld := t.Field(folded)
+		return fld.Name, fld.Type, true
+	}
+
+	for _, fld := range embedded {
+		if p, ft, ok := fileField(derefType(fld.Type), key, tagName); ok {
+			return fmt.Sprintf("%s.%s", fld.Name, p), ft, true
+		}
+	}
+
+	return "", nil, false
+}
@@ -0,0 +1,149 @@
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGatherWithReport(t *testing.T) {
+	type testConfig struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+		Port    int
+		Unset   string
+	}
+
+	t.Parallel()
+
+	opts := Options().
+		SetArgs([]string{
+			"cmd",
+			"--config-file", "./tests/config.simple.yml",
+			"--port", "3000",
+		}).
+		SetEnv(map[string]string{"APP_NAME": "env name"}).
+		SetBasePath("./tests/simple.yaml").
+		SetDefaultsMap(map[string]interface{}{
+			"Port": 8080,
+		}).
+		SetArgsFileOverride("--config-file").
+		SetArg("--port", "Port").
+		SetVar("APP_NAME", "Name")
+
+	cfg := &testConfig{}
+	r, err := GatherWithReport(opts, cfg)
+	if err != nil {
+		t.Fatalf("GatherWithReport() unexpected error = %v", err)
+	}
+
+	name, ok := r.Field("Name")
+	if !ok {
+		t.Fatalf("Report.Field() missing Name")
+	}
+	if name.Source != SourceVars || name.Origin != "APP_NAME" || name.Value != "env name" {
+		t.Errorf("Report.Field(Name) = %+v", name)
+	}
+	wantOverridden := []Assignment{
+		{Source: SourceBaseFile, Origin: "./tests/simple.yaml", Value: "example"},
+		{Source: SourceArgsFile, Origin: "./tests/config.simple.yml", Value: "example-config-file-pattern"},
+	}
+	if !reflect.DeepEqual(name.Overridden, wantOverridden) {
+		t.Errorf("Report.Field(Name).Overridden = %+v, want %+v", name.Overridden, wantOverridden)
+	}
+
+	port, _ := r.Field("Port")
+	if port.Source != SourceArgs || port.Origin != "--port" || port.Value != 3000 {
+		t.Errorf("Report.Field(Port) = %+v", port)
+	}
+	if len(port.Overridden) != 1 || port.Overridden[0].Source != SourceDefaultsMap {
+		t.Errorf("Report.Field(Port).Overridden = %+v", port.Overridden)
+	}
+
+	version, _ := r.Field("Version")
+	if version.Source != SourceArgsFile || len(version.Overridden) != 1 {
+		t.Errorf("Report.Field(Version) = %+v", version)
+	}
+
+	unset, ok := r.Field("Unset")
+	if !ok || unset.IsSet() {
+		t.Errorf("Report.Field(Unset) = %+v, want unset", unset)
+	}
+
+	out := r.String()
+	for _, want := range []string{
+		"Name: env name (Vars APP_NAME) <- example-config-file-pattern (ArgsFile ./tests/config.simple.yml)",
+		"Unset: (not set)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Report.String() = %q, missing %q", out, want)
+		}
+	}
+}
+
+func Test_settings_fileFieldPaths(t *testing.T) {
+	type testConfig struct {
+		Name   string `yaml:"name" json:"name"`
+		Nested struct {
+			Count int `yaml:"count"`
+		} `yaml:"nested"`
+		Ignored   string `yaml:"-"`
+		Untagged  string
+		CamelCase string
+	}
+
+	s := &settings{
+		fieldTypeMap: map[string]reflect.Type{},
+		out:          &testConfig{},
+	}
+	if err := s.determineFieldTypes(); err != nil {
+		t.Fatalf("unexpected error determining fields: %v", err)
+	}
+
+	paths := []string{}
+	s.fileFieldPaths(map[interface{}]interface{}{
+		"name":      "n",
+		"Ignored":   "i",
+		"untagged":  "u",
+		"camelCase": "c",
+		"nested": map[interface{}]interface{}{
+			"count": 1,
+		},
+	}, reflect.TypeOf(s.out), "yaml", "", &paths)
+
+	got := map[string]bool{}
+	for _, p := range paths {
+		got[p] = true
+	}
+	want := map[string]bool{"Name": true, "Nested.Count": true, "Untagged": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("settings.fileFieldPaths() = %v, want %v", got, want)
+	}
+}
+
+func Test_fileField(t *testing.T) {
+	type testConfig struct {
+		Lower string `json:"a"`
+		Upper string `json:"A"`
+		Dash  string `json:"-,"`
+		Skip  string `json:"-"`
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"A", "Upper"},
+		{"a", "Lower"},
+		{"-", "Dash"},
+		{"Skip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, _, _ := fileField(reflect.TypeOf(testConfig{}), tt.key, "json")
+			if got != tt.want {
+				t.Errorf("fileField() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,59 +1,146 @@
 package settings
 
 import (
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-
-	"gopkg.in/yaml.v2"
 )
 
+// errUnsettable is returned by setPath when the field can't be set
+var errUnsettable = errors.New("field cannot be set")
+
 var (
-	commaRE     = regexp.MustCompile(`\,\s?`)
-	dotRE       = regexp.MustCompile(`\.`)
-	settingsExt = []string{".yml", ".yaml", ".json", ""}
-	timeType    = reflect.TypeOf(time.Now())
+	dotRE               = regexp.MustCompile(`\.`)
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Now())
 )
 
 type settings struct {
-	fieldTypeMap map[string]reflect.Type
-	out          interface{}
+	appendSlices  bool
+	args          []string
+	cmdTags       map[string]string
+	command       string
+	commandArgs   []string
+	defaultTags   map[string]string
+	descTags      map[string]string
+	dotenv        map[string]dotenvValue
+	emptyValues   EmptyPolicy
+	environList   func() []string
+	fieldOrder    []string
+	fieldTypeMap  map[string]reflect.Type
+	fsys          []fs.FS
+	globalArgs    []string
+	layoutTags    map[string]string
+	listSeparator string
+	lookupEnv     func(string) (string, bool)
+	out           interface{}
+	plan          *fieldPlan
+	report        *Report
+	requiredTags  []string
+	sepTags       map[string]string
+	validateTags  map[string]string
+	walking       map[reflect.Type]bool
+	watched       []string
 }
 
 // Gather compiles configuration from various sources and
 // iteratively builds up the out object with the values
 // that are retrieved successively from the following sources:
-// 1. base settings file
-// 2. defaults as configured in options (*diverges from github.com/brozeph/settings-lib)
-// 3. override files (from command line)
-// 4. override files (from environment)
-// 5. command line arguments
-// 6. environment variables
+// 1. default struct tags
+// 2. base settings file
+// 3. defaults as configured in options (*diverges from github.com/brozeph/settings-lib)
+// 4. override files (from command line)
+// 5. override files (from environment)
+// 6. command line arguments
+// 7. environment variables (and any dotenv files)
 func Gather(opts ReadOptions, out any) error {
-	s := settings{
-		fieldTypeMap: map[string]reflect.Type{},
-		out:          out,
+	_, err := gather(opts, out)
+	return err
+}
+
+// GatherWithReport compiles configuration exactly as Gather does and
+// additionally returns a Report describing, for every field in the out
+// struct, which source supplied its final value and which earlier values
+// were overridden along the way
+func GatherWithReport(opts ReadOptions, out any) (*Report, error) {
+	s, err := gather(opts, out)
+	return s.report, err
+}
+
+// Load gathers settings, exactly as Gather does, into a new T and returns it
+func Load[T any](opts ReadOptions) (T, error) {
+	var out T
+	err := Gather(opts, &out)
+	return out, err
+}
+
+// MustLoad is like Load but panics when settings cannot be gathered
+func MustLoad[T any](opts ReadOptions) T {
+	out, err := Load[T](opts)
+	if err != nil {
+		panic(err)
+	}
+
+	return out
+}
+
+func gather(opts ReadOptions, out any) (*settings, error) {
+	s := &settings{
+		appendSlices:  opts.AppendSlices,
+		args:          opts.Args,
+		emptyValues:   opts.EmptyValues,
+		environList:   opts.Environ,
+		fieldTypeMap:  map[string]reflect.Type{},
+		fsys:          opts.FS,
+		listSeparator: opts.ListSeparator,
+		lookupEnv:     opts.LookupEnv,
+		out:           out,
 	}
 
 	// create an internal map for each field and its type
 	if err := s.determineFieldTypes(); err != nil {
-		return err
+		return s, err
 	}
 
+	// track the source of each field as the layers are applied
+	s.report = newReport(s.fieldTypeMap, s.plan.aliases)
+
 	// process arg and env tags on struct
-	s.reflectTagOverrideArgs(out, &opts)
+	s.plan.applyTags(&opts)
+
+	// print usage and stop when help is requested (i.e. -h or --help)
+	if opts.HelpOutput != nil && s.helpRequested(opts.ArgsMap) {
+		fmt.Fprint(opts.HelpOutput, s.usage(opts))
+		return s, SettingsHelpRequested()
+	}
+
+	// field level errors are collected from each layer so that
+	// every problem can be reported together
+	var errs SettingsErrors
+
+	// read dotenv files (used alongside environment variables)
+	if err := s.readDotenvFiles(opts.DotenvFiles); err != nil {
+		return s, err
+	}
+
+	// apply values from default tags (lowest precedence)
+	errs = errs.append(s.applyDefaultTags())
 
 	// read in base path (should be the base config file)
 	if err := s.readBaseSettings(opts.BasePath); err != nil {
-		return err
+		return s, errs.append(err).err()
 	}
 
 	// apply default mapped values
@@ -61,71 +148,160 @@ func Gather(opts ReadOptions, out any) error {
 	// apply the values that match the field names in the
 	// inbound pointer argument that is an interface{} with
 	// variable name "s"
-	if err := s.applyDefaultsMap(opts.DefaultsMap); err != nil {
-		return err
-	}
+	errs = errs.append(s.applyDefaultsMap(opts.DefaultsMap))
 
 	// iterate each arg file override
 	if err := s.searchForArgOverrides(opts.ArgsFileOverride); err != nil {
-		return err
+		return s, errs.append(err).err()
 	}
 
 	// read any applicable environment override files
 	if err := s.searchForEnvOverrides(opts.EnvOverride, opts.EnvSearchPaths, opts.EnvSearchPattern); err != nil {
-		return err
+		return s, errs.append(err).err()
 	}
 
-	// apply command line arguments
-	if err := s.applyArgs(opts.ArgsMap); err != nil {
-		return err
+	// apply command line arguments (from the parsed flag.FlagSet when provided)
+	if opts.FlagSet != nil {
+		errs = errs.append(s.applyFlags(opts.FlagSet, opts.ArgsMap))
+	} else {
+		errs = errs.append(s.applyArgs(opts.ArgsMap))
 	}
+	s.report.Command = s.command
 
 	// apply environment variables
-	if err := s.applyVars(opts.VarsMap); err != nil {
-		return err
-	}
+	errs = errs.append(s.applyVars(opts.VarsMap))
 
-	return nil
+	// ensure each required field was set by at least one source
+	errs = errs.append(s.checkRequired(opts.Required))
+
+	// validate the final values
+	errs = errs.append(s.validate())
+
+	return s, errs.err()
 }
 
 func (s *settings) applyArgs(a map[string]string) error {
+	// split the arguments before and after any subcommand (i.e. svc serve --port 80)
+	s.selectCommand(s.osArgs(), a, true)
+
+	return s.readArgs(a)
+}
+
+// readArgs applies the arguments selected for each ArgsMap key (see argsFor)
+// to the field it is mapped to
+func (s *settings) readArgs(a map[string]string) error {
+	var errs SettingsErrors
 	eq := []byte(`=`)
-	totalArgs := len(os.Args)
 
 	// iterate each element in args map
-	for arg, field := range a {
+	for _, key := range sortedKeys(a) {
+		field := a[key]
+
+		// arguments scoped to a subcommand (i.e. "serve --port") are only read after it
+		arg, osArgs := s.argsFor(key, field)
+		totalArgs := len(osArgs)
+
+		// repeated arguments (i.e. --tag a --tag b) accumulate for slice fields
+		t, ok := s.fieldType(s.resolvePath(field))
+		repeat := ok && isList(t)
+
+		// bool switches don't require a value and can be negated (i.e. --no-verbose)
+		flag := ok && isBool(t)
+		negated := ""
+		if flag && strings.HasPrefix(arg, "--") {
+			negated = "--no-" + arg[2:]
+		}
+
 		// iterate each arg provided to the application
-		for i, oa := range os.Args {
+		values := []string{}
+		origin := arg
+		for i := 0; i < totalArgs; i++ {
+			oa := osArgs[i]
+
 			// check for `--cli-arg=` scenario (where value is specified after =)
 			al := len(arg)
-			if len(oa) > al && oa[0:al] == arg && oa[al] == eq[0] {
-				// we have a match...
-				if err := s.setFieldValue(
-					field,
-					s.cleanArgValue(oa[al:]),
-					"Args"); err != nil {
-					return err
-				}
+			switch {
+			case len(oa) > al && oa[0:al] == arg && oa[al] == eq[0]:
+				values = append(values, s.cleanArgValue(oa[al:]))
+				origin = arg
+			case oa == arg && flag:
+				// bool switches never take the next argument as a value (as with
+				// the flag package), so use --verbose=false or --no-verbose instead
+				values = append(values, "true")
+				origin = arg
+			case oa == arg && i < totalArgs-1:
+				// check for direct arg match
+				values = append(values, s.cleanArgValue(osArgs[i+1]))
 
+				// next os.Arg is the value, skip trying to match it
+				i++
+			case negated != "" && oa == negated:
+				values = append(values, "false")
+				origin = negated
+			default:
+				continue
+			}
+
+			// the last of any repeated bool switches is used
+			if !repeat && !flag {
 				break
 			}
+		}
 
-			// check for direct arg match
-			if oa == arg && i < totalArgs-1 {
-				if err := s.setFieldValue(
-					field,
-					s.cleanArgValue(os.Args[i+1]),
-					"Args"); err != nil {
-					return err
-				}
+		if len(values) > 0 {
+			if err := s.setFieldValues(field, values, "Args"); err != nil {
+				errs = errs.append(withSource(err, SourceArgs, origin, strings.Join(values, " ")))
+			} else {
+				s.track(field, SourceArgs, origin)
+			}
+		}
 
-				// next os.Arg is the value, skip trying to match it
-				break
+		// arguments named for an entry within a map or slice field (i.e.
+		// --limits.orders=10 or --backends[1].port=8080)
+		if ok && hasEntries(t) {
+			errs = errs.append(s.applyEntryArgs(osArgs, arg, field, t))
+		}
+	}
+
+	return errs.err()
+}
+
+func (s *settings) applyEntryArgs(osArgs []string, arg string, field string, t reflect.Type) error {
+	var errs SettingsErrors
+
+	for i, oa := range osArgs {
+		if !strings.HasPrefix(oa, arg+".") && !strings.HasPrefix(oa, arg+"[") {
+			continue
+		}
+
+		// check for `--cli-arg.key=` and `--cli-arg.key value` scenarios
+		name, value, ok := strings.Cut(oa, "=")
+		if !ok {
+			if i >= len(osArgs)-1 {
+				continue
 			}
+
+			value = osArgs[i+1]
+		}
+
+		// index brackets are equivalent to a dotted index (i.e. [1].port is .1.port)
+		key := strings.NewReplacer("[", ".", "]", "").Replace(name[len(arg):])
+		subPath, ok := entryPath(t, strings.Split(strings.TrimPrefix(key, "."), "."), ".", false)
+		if !ok {
+			continue
 		}
+
+		entryField := fmt.Sprintf("%s.%s", field, subPath)
+		value = s.cleanArgValue(value)
+		if err := s.setFieldValue(entryField, value, "Args"); err != nil {
+			errs = errs.append(withSource(err, SourceArgs, name, value))
+			continue
+		}
+
+		s.track(entryField, SourceArgs, name)
 	}
 
-	return nil
+	return errs.err()
 }
 
 func (s *settings) applyVars(v map[string]string) error {
@@ -134,25 +310,68 @@ func (s *settings) applyVars(v map[string]string) error {
 		return nil
 	}
 
+	var errs SettingsErrors
+
 	// iterate the vars map
-	for evar, fieldPath := range v {
-		// lookup the var from the environment
-		v := os.Getenv(evar)
+	for _, evar := range sortedKeys(v) {
+		fieldPath := v[evar]
+		errs = errs.append(s.applyVar(evar, fieldPath))
+
+		// variables named for an entry within a map or slice field (i.e.
+		// LIMITS_ORDERS or BACKENDS_0_HOST)
+		if t, ok := s.fieldType(s.resolvePath(fieldPath)); ok && hasEntries(t) {
+			prefix := evar + "_"
+			for _, name := range s.environNames() {
+				if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+					continue
+				}
 
-		// if there is no value, continue on
-		if v == "" {
-			continue
+				subPath, ok := entryPath(t, strings.Split(name[len(prefix):], "_"), "_", true)
+				if ok {
+					errs = errs.append(s.applyVar(name, fmt.Sprintf("%s.%s", fieldPath, subPath)))
+				}
+			}
 		}
+	}
 
-		// set the value
-		if err := s.setFieldValue(fieldPath, v, "Vars"); err != nil {
-			return err
-		}
+	return errs.err()
+}
+
+func (s *settings) applyVar(evar string, fieldPath string) error {
+	// lookup the var from the environment (or dotenv files)
+	v, src, origin, ok := s.getenv(evar)
+
+	// if there is no value, continue on
+	if !ok {
+		return nil
 	}
 
+	// set the value
+	if err := s.setFieldValue(fieldPath, v, "Vars"); err != nil {
+		return withSource(err, src, origin, v)
+	}
+
+	s.track(fieldPath, src, origin)
+
 	return nil
 }
 
+func (s *settings) applyDefaultTags() error {
+	var errs SettingsErrors
+
+	for _, fieldPath := range sortedKeys(s.plan.defaults) {
+		value := s.plan.defaults[fieldPath]
+		if err := s.setFieldValue(fieldPath, value, "default"); err != nil {
+			errs = errs.append(withSource(err, SourceDefaultTag, "", value))
+			continue
+		}
+
+		s.track(fieldPath, SourceDefaultTag, "")
+	}
+
+	return errs.err()
+}
+
 func (s *settings) applyDefaultsMap(d map[string]interface{}) error {
 	// only apply defaults where applicable
 	if len(d) == 0 {
@@ -162,25 +381,34 @@ func (s *settings) applyDefaultsMap(d map[string]interface{}) error {
 	a := []struct {
 		defVal    interface{}
 		fieldName string
-		fieldVal  reflect.Value
 	}{}
 
+	var errs SettingsErrors
+
 	// validate each default value type before setting
-	for fieldName, defVal := range d {
-		if t, ok := s.fieldTypeMap[fieldName]; ok {
+	for _, name := range sortedKeys(d) {
+		defVal := d[name]
+		fieldName := s.resolvePath(name)
+
+		if t, ok := s.fieldType(fieldName); ok {
 			if t.Kind() != reflect.ValueOf(defVal).Kind() {
 				// type mismatch error
-				return SettingsFieldTypeMismatch(
+				errs = errs.append(withSource(SettingsFieldTypeMismatch(
 					fieldName,
 					t.Kind(),
-					reflect.ValueOf(defVal).Kind())
+					reflect.ValueOf(defVal).Kind()), SourceDefaultsMap, "", fmt.Sprint(defVal)))
+				continue
 			}
 
+			// pointers to structs along the path are only allocated (and map
+			// entries only created) when applied
 			fieldVal := s.findOutFieldValue(fieldName)
+			_, static := s.fieldTypeMap[fieldName]
 
-			if !fieldVal.CanSet() {
+			if static && fieldVal.IsValid() && !fieldVal.CanSet() {
 				// unable to set the value
-				return SettingsFieldSetError(fieldName, t.Kind())
+				errs = errs.append(withSource(SettingsFieldSetError(fieldName, t.Kind()), SourceDefaultsMap, "", fmt.Sprint(defVal)))
+				continue
 			}
 
 			a = append(
@@ -188,27 +416,129 @@ func (s *settings) applyDefaultsMap(d map[string]interface{}) error {
 				struct {
 					defVal    interface{}
 					fieldName string
-					fieldVal  reflect.Value
 				}{
 					defVal,
 					fieldName,
-					fieldVal,
 				})
 
 			continue
 		}
 
 		// default field is not in the out struct
-		return SettingsFieldDoesNotExist("DefaultsMap", fieldName)
+		errs = errs.append(withSource(SettingsFieldDoesNotExist("DefaultsMap", name), SourceDefaultsMap, "", fmt.Sprint(defVal)))
+	}
+
+	// defaults are only applied when every value is valid
+	if len(errs) > 0 {
+		return errs
 	}
 
 	// iterate the default to apply and apply them
 	for _, aa := range a {
 		dv := reflect.ValueOf(aa.defVal)
-		aa.fieldVal.Set(dv)
+		if err := s.setOutFieldValue(aa.fieldName, dv); err != nil {
+			errs = errs.append(withSource(SettingsFieldSetError(aa.fieldName, dv.Kind(), err), SourceDefaultsMap, "", fmt.Sprint(aa.defVal)))
+			continue
+		}
+
+		s.track(aa.fieldName, SourceDefaultsMap, "")
 	}
 
-	return nil
+	return errs.err()
+}
+
+// checkRequired returns an error for each required field that no source set
+func (s *settings) checkRequired(required []string) error {
+	var errs SettingsErrors
+
+	// combine required tags with any explicitly required fields
+	paths := map[string]bool{}
+	for _, p := range s.plan.required {
+		paths[p] = true
+	}
+	for _, p := range required {
+		paths[s.resolvePath(p)] = true
+	}
+
+	for _, fieldPath := range sortedKeys(paths) {
+		// map entries (i.e. Limits.orders) are only reported once set
+		fr, ok := s.report.Field(fieldPath)
+		if t, valid := s.fieldType(fieldPath); !ok && (!valid || !isLeaf(t)) {
+			errs = errs.append(SettingsFieldDoesNotExist("Required", fieldPath))
+			continue
+		}
+
+		// provenance is used so that explicit zero values count as set
+		if !fr.IsSet() {
+			errs = errs.append(SettingsFieldRequired(fieldPath))
+		}
+	}
+
+	return errs.err()
+}
+
+// osArgs returns the command line arguments provided via ReadOptions.SetArgs,
+// or os.Args when none were provided, up to the -- terminator (arguments
+// after it are positional and never read as switches or values)
+func (s *settings) osArgs() []string {
+	args := s.args
+	if args == nil {
+		args = os.Args
+	}
+
+	for i, a := range args {
+		if a == "--" {
+			return args[:i]
+		}
+	}
+
+	return args
+}
+
+// expandFlags splits combined short switches (i.e. -vq) into separate
+// switches (-v -q) when every letter is mapped to a bool field
+func (s *settings) expandFlags(osArgs []string, a map[string]string) []string {
+	flags := map[rune]bool{}
+	for key, field := range a {
+		_, arg := splitArg(key)
+		if len(arg) != 2 || arg[0] != '-' || arg[1] == '-' {
+			continue
+		}
+
+		if t, ok := s.fieldType(s.resolvePath(field)); ok && isBool(t) {
+			flags[rune(arg[1])] = true
+		}
+	}
+
+	expanded := []string{}
+	for _, oa := range osArgs {
+		if !isCombined(oa, flags) {
+			expanded = append(expanded, oa)
+			continue
+		}
+
+		for _, r := range oa[1:] {
+			expanded = append(expanded, "-"+string(r))
+		}
+	}
+
+	return expanded
+}
+
+// isCombined returns true when the arg is a single dash followed by two or
+// more letters that are each a short bool switch
+func isCombined(oa string, flags map[rune]bool) bool {
+	if len(oa) < 3 || oa[0] != '-' || oa[1] == '-' {
+		return false
+	}
+
+	for _, r := range oa[1:] {
+		if !flags[r] {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (settings) cleanArgValue(v string) string {
@@ -220,14 +550,14 @@ func (settings) cleanArgValue(v string) string {
 
 	for i, b := range charCheck {
 		// look for = as first char and remove it
-		if v[0] == b && i == 0 {
+		if i == 0 && v[0] == b {
 			v = v[1:]
 			continue
 		}
 
 		// look for quotes (' or " surrounding the value)
 		l := len(v)
-		if v[0] == v[l-1] && v[0] == b {
+		if l > 1 && v[0] == v[l-1] && v[0] == b {
 			v = v[1 : l-1]
 		}
 	}
@@ -240,48 +570,41 @@ func (s *settings) determineFieldTypes() error {
 	if s.out == nil {
 		return SettingsOutCannotBeNil()
 	}
-	ct := reflect.TypeOf(s.out)
-
 	// when a pointer, find the type that it is pointing to
-	for ct.Kind() == reflect.Ptr {
-		ct = ct.Elem()
-	}
+	ct := derefType(reflect.TypeOf(s.out))
 
-	// check for a map target
-	for ct.Kind() == reflect.Map {
-		ct = ct.Elem()
+	// check for a map target (of structs)
+	et := ct
+	for et.Kind() == reflect.Map {
+		et = derefType(et.Elem())
 	}
 
 	// if the target isn't a map, then it must be a struct of some sort
-	if ct.Kind() != reflect.Struct {
+	if et.Kind() != reflect.Struct {
 		// target is not suitable to populate
-		return SettingsTypeDiscoveryError(ct.Kind())
+		return SettingsTypeDiscoveryError(et.Kind())
 	}
 
-	fields := ct.NumField()
-	for i := 0; i < fields; i++ {
-		field := ct.FieldByIndex([]int{i})
-		s.iterateFields("", field)
-	}
+	// reuse the fields discovered for the type during any earlier gather
+	s.plan = planFor(ct)
+	s.fieldTypeMap = s.plan.fieldTypeMap
 
 	return nil
 }
 
 func (s *settings) determineFileType(path string) (string, error) {
 	ext := filepath.Ext(path)
-	var t string
-	switch ext {
-	case ".yml", ".yaml":
-		t = "yaml"
-	case ".json":
-		t = "json"
-	default:
-		return t, SettingsFileTypeError(path, ext)
+
+	f, ok := formatByExt(ext)
+	if !ok {
+		return "", SettingsFileTypeError(path, ext)
 	}
 
-	return t, nil
+	return f.name, nil
 }
 
+// findOutFieldValue walks the out struct (and any map entries) to the field,
+// returning an invalid value when a nil pointer or missing map entry is found
 func (s *settings) findOutFieldValue(fieldPath string) reflect.Value {
 	if fieldPath == "" {
 		return reflect.Value{}
@@ -299,16 +622,178 @@ func (s *settings) findOutFieldValue(fieldPath string) reflect.Value {
 	// iterate through each value until we get to the correct sub field
 	for _, sf := range deepFields {
 		// ensure we are working with the underlying value
-		for v.Type().Kind() == reflect.Ptr {
+		for v.Kind() == reflect.Ptr {
 			v = v.Elem()
 		}
 
-		v = v.FieldByName(sf)
+		switch v.Kind() {
+		case reflect.Struct:
+			v = v.FieldByName(sf)
+		case reflect.Map:
+			key, err := parseValue(v.Type().Key(), sf, valueFormat{})
+			if err != nil {
+				return reflect.Value{}
+			}
+			v = v.MapIndex(key)
+		case reflect.Array, reflect.Slice:
+			i, err := strconv.Atoi(sf)
+			if err != nil || i < 0 || i >= v.Len() {
+				return reflect.Value{}
+			}
+			v = v.Index(i)
+		default:
+			return reflect.Value{}
+		}
+
+		if !v.IsValid() {
+			return v
+		}
 	}
 
 	return v
 }
 
+// setOutFieldValue sets the field at the path within the out struct
+func (s *settings) setOutFieldValue(fieldPath string, val reflect.Value) error {
+	return setPath(reflect.ValueOf(s.out), dotRE.Split(fieldPath, -1), val)
+}
+
+// setPath sets the value at the path of field names (or map keys) within v,
+// allocating nil pointers and creating maps and map entries along the way
+func setPath(v reflect.Value, names []string, val reflect.Value) error {
+	if len(names) == 0 {
+		if !v.CanSet() || !val.Type().AssignableTo(v.Type()) {
+			return errUnsettable
+		}
+
+		v.Set(val)
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if !v.CanSet() {
+				return errUnsettable
+			}
+
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return setPath(v.FieldByName(names[0]), names[1:], val)
+	case reflect.Map:
+		key, err := parseValue(v.Type().Key(), names[0], valueFormat{})
+		if err != nil {
+			return fmt.Errorf("invalid map key %q: %w", names[0], err)
+		}
+
+		if v.IsNil() {
+			if !v.CanSet() {
+				return errUnsettable
+			}
+
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		// map entries aren't addressable, so the entry is copied, updated and replaced
+		entry := reflect.New(v.Type().Elem()).Elem()
+		if ev := v.MapIndex(key); ev.IsValid() {
+			entry.Set(ev)
+		}
+
+		if err := setPath(entry, names[1:], val); err != nil {
+			return err
+		}
+
+		v.SetMapIndex(key, entry)
+		return nil
+	case reflect.Array, reflect.Slice:
+		i, err := strconv.Atoi(names[0])
+		if err != nil || i < 0 {
+			return fmt.Errorf("invalid index %q", names[0])
+		}
+
+		// slices grow as needed to include the index
+		if i >= v.Len() {
+			if v.Kind() == reflect.Array {
+				return fmt.Errorf("index %d out of range for array of length %d", i, v.Len())
+			}
+
+			if !v.CanSet() {
+				return errUnsettable
+			}
+
+			grown := reflect.MakeSlice(v.Type(), i+1, i+1)
+			reflect.Copy(grown, v)
+			v.Set(grown)
+		}
+
+		return setPath(v.Index(i), names[1:], val)
+	}
+
+	return errUnsettable
+}
+
+// fieldType returns the type of the field at the path, including paths
+// that traverse map keys (i.e. "Limits.orders" for a map[string]int)
+func (s *settings) fieldType(fieldPath string) (reflect.Type, bool) {
+	if t, ok := s.fieldTypeMap[fieldPath]; ok {
+		return t, true
+	}
+
+	t := reflect.TypeOf(s.out)
+	dynamic := false
+
+	for _, name := range dotRE.Split(fieldPath, -1) {
+		t = derefType(t)
+
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := t.FieldByName(name)
+			if !ok || !f.IsExported() {
+				return nil, false
+			}
+			t = f.Type
+		case reflect.Map:
+			dynamic = true
+			t = t.Elem()
+		case reflect.Array, reflect.Slice:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || (t.Kind() == reflect.Array && i >= t.Len()) {
+				return nil, false
+			}
+			dynamic = true
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+	}
+
+	// only paths through a map or slice aren't already in the field type map;
+	// entries that aren't leaves (i.e. Backends.0) may be set from JSON
+	if !dynamic {
+		return nil, false
+	}
+
+	return t, true
+}
+
+// resolvePath returns the full path of a field promoted from an embedded
+// struct (i.e. "Port" for "Common.Port"), or the path as is
+func (s *settings) resolvePath(fieldPath string) string {
+	if s.plan != nil {
+		if p, ok := s.plan.aliases[fieldPath]; ok {
+			return p
+		}
+	}
+
+	return fieldPath
+}
+
 func (s *settings) iterateFields(parentPrefix string, field reflect.StructField) {
 	fieldName := field.Name
 
@@ -317,19 +802,42 @@ func (s *settings) iterateFields(parentPrefix string, field reflect.StructField)
 		fieldName = fmt.Sprintf("%s.%s", parentPrefix, fieldName)
 	}
 
-	// if field is not a struct, store the type
-	if field.Type.Kind() != reflect.Struct {
+	// if field is not a struct (or is a time or text value), store the type
+	if isLeaf(field.Type) {
 		s.fieldTypeMap[fieldName] = field.Type
 		return
 	}
 
-	fields := field.Type.NumField()
+	// walk into structs and pointers to structs
+	ft := derefType(field.Type)
+	if !s.walk(ft) {
+		return
+	}
+	defer delete(s.walking, ft)
+
+	fields := ft.NumField()
 	for i := 0; i < fields; i++ {
-		f := field.Type.FieldByIndex([]int{i})
+		f := ft.FieldByIndex([]int{i})
 		s.iterateFields(fieldName, f)
 	}
 }
 
+// walk marks the struct type as being walked, returning false when the type
+// is already being walked (i.e. a recursive Next *Node field)
+func (s *settings) walk(t reflect.Type) bool {
+	if s.walking == nil {
+		s.walking = map[reflect.Type]bool{}
+	}
+
+	if s.walking[t] {
+		return false
+	}
+
+	s.walking[t] = true
+
+	return true
+}
+
 func (s *settings) reflectTagOverrideArgs(out any, opts *ReadOptions, pFldNm ...string) {
 	// read tag values for each field on out using reflection
 	var ct reflect.Type
@@ -341,9 +849,12 @@ func (s *settings) reflectTagOverrideArgs(out any, opts *ReadOptions, pFldNm ...
 	}
 
 	// when a pointer, find the type that it is pointing to
-	for ct.Kind() == reflect.Ptr {
-		ct = ct.Elem()
+	ct = derefType(ct)
+
+	if !s.walk(ct) {
+		return
 	}
+	defer delete(s.walking, ct)
 
 	// iterate each field on the struct
 	flds := ct.NumField()
@@ -358,13 +869,29 @@ func (s *settings) reflectTagOverrideArgs(out any, opts *ReadOptions, pFldNm ...
 		}
 
 		// recursively handle structs
-		if fld.Type.Kind() == reflect.Struct {
+		if !isLeaf(fld.Type) {
+			// read "cmd" tag (the arguments after the subcommand apply to the struct)
+			if cmd := fld.Tag.Get("cmd"); cmd != "" {
+				if s.cmdTags == nil {
+					s.cmdTags = map[string]string{}
+				}
+
+				s.cmdTags[cmd] = fldNm
+			}
+
 			s.reflectTagOverrideArgs(fld.Type, opts, fldNm)
 			continue
 		}
 
-		// read "arg" tag
+		// fields are listed in declaration order by Usage
+		s.fieldOrder = append(s.fieldOrder, fldNm)
+
+		// read "arg" tag (scoped to the subcommand of the struct, i.e. "serve --port")
 		arg := fld.Tag.Get("arg")
+		if cmd, ok := commandFor(s.cmdTags, fldNm); ok && arg != "" {
+			arg = fmt.Sprintf("%s %s", cmd, arg)
+		}
+
 		if arg != "" {
 			// ensure args map ready
 			if opts.ArgsMap == nil {
@@ -374,6 +901,56 @@ func (s *settings) reflectTagOverrideArgs(out any, opts *ReadOptions, pFldNm ...
 			opts.ArgsMap[arg] = fldNm
 		}
 
+		// read "default" tag
+		if def, ok := fld.Tag.Lookup("default"); ok {
+			if s.defaultTags == nil {
+				s.defaultTags = map[string]string{}
+			}
+
+			s.defaultTags[fldNm] = def
+		}
+
+		// read "desc" tag
+		if desc := fld.Tag.Get("desc"); desc != "" {
+			if s.descTags == nil {
+				s.descTags = map[string]string{}
+			}
+
+			s.descTags[fldNm] = desc
+		}
+
+		// read "layout" tag
+		if layout := fld.Tag.Get("layout"); layout != "" {
+			if s.layoutTags == nil {
+				s.layoutTags = map[string]string{}
+			}
+
+			s.layoutTags[fldNm] = layout
+		}
+
+		// read "required" tag
+		if req, err := strconv.ParseBool(fld.Tag.Get("required")); err == nil && req {
+			s.requiredTags = append(s.requiredTags, fldNm)
+		}
+
+		// read "sep" tag
+		if sep := fld.Tag.Get("sep"); sep != "" {
+			if s.sepTags == nil {
+				s.sepTags = map[string]string{}
+			}
+
+			s.sepTags[fldNm] = sep
+		}
+
+		// read "validate" tag
+		if rules := fld.Tag.Get("validate"); rules != "" {
+			if s.validateTags == nil {
+				s.validateTags = map[string]string{}
+			}
+
+			s.validateTags[fldNm] = rules
+		}
+
 		// read "env" tag
 		env := fld.Tag.Get("env")
 		if env != "" {
@@ -393,37 +970,56 @@ func (s *settings) readBaseSettings(path string) error {
 		return nil
 	}
 
-	if _, err := os.Stat(path); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			// base path doesn't exist
+	// base path may exist in more than one filesystem
+	found, err := s.statFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, fsys := range found {
+		if err := s.readFile(fsys, path, SourceBaseFile); err != nil {
 			return err
 		}
+	}
 
-		// unable to stat the file for other reasons...
-		return SettingsFileReadError(path, err.Error())
+	return nil
+}
+
+func (s *settings) readFile(fsys fs.FS, path string, src Source) error {
+	t, in, err := s.loadFile(fsys, path)
+	if err != nil {
+		return err
 	}
 
-	if err := s.unmarshalFile(path, s.out); err != nil {
+	if err := s.unmarshalData(path, t, in, s.out); err != nil {
 		return err
 	}
 
+	// determine which fields the file supplied when tracking sources; the
+	// fields are left unreported for decoders that only accept structs
+	var doc interface{}
+	if s.report != nil && s.unmarshalData(path, t, in, &doc) == nil {
+		paths := []string{}
+		s.fileFieldPaths(doc, reflect.TypeOf(s.out), t, "", &paths)
+		for _, p := range paths {
+			s.track(p, src, path)
+		}
+	}
+
 	return nil
 }
 
-func (s *settings) readOverrideFile(path string) error {
-	if _, err := os.Stat(path); err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			// base path doesn't exist
-			return err
-		}
-
-		// unable to stat the file for other reasons...
-		return SettingsFileReadError(path, err.Error())
+func (s *settings) readOverrideFile(path string, src Source) error {
+	found, err := s.statFile(path)
+	if err != nil {
+		return err
 	}
 
 	// unmarshal over the top of the base...
-	if err := s.unmarshalFile(path, s.out); err != nil {
-		return err
+	for _, fsys := range found {
+		if err := s.readFile(fsys, path, src); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -434,12 +1030,14 @@ func (s *settings) searchForArgOverrides(args []string) error {
 		return nil
 	}
 
+	osArgs := s.osArgs()
+
 	for _, a := range args {
 		var path string
 		eq := []byte(`=`)
-		totalArgs := len(os.Args)
+		totalArgs := len(osArgs)
 
-		for i, oa := range os.Args {
+		for i, oa := range osArgs {
 			// check for `--cli-arg=` scenario (where value is specified after =)
 			al := len(a)
 			if len(oa) > al && oa[0:al] == a && oa[al] == eq[0] {
@@ -452,14 +1050,14 @@ func (s *settings) searchForArgOverrides(args []string) error {
 			// check for direct arg match
 			if oa == a && i < totalArgs-1 {
 				// path should be the next argument specified
-				path = s.cleanArgValue(os.Args[i+1])
+				path = s.cleanArgValue(osArgs[i+1])
 				break
 			}
 		}
 
 		// we found a path...
 		if path != "" {
-			if err := s.readOverrideFile(path); err != nil {
+			if err := s.readOverrideFile(path, SourceArgsFile); err != nil {
 				return err
 			}
 		}
@@ -478,16 +1076,17 @@ func (s *settings) searchForEnvOverrides(vars []string, searchPaths []string, fi
 	}
 
 	var extensionSearch = func(sp string) (bool, error) {
-		for _, ext := range settingsExt {
+		// search for each registered extension (and no extension at all)
+		for _, ext := range append(formatExtensions(), "") {
 			spf := fmt.Sprintf("%s%s", sp, ext)
 
 			// continue when the file can't be opened (presumably does not exist)
-			if _, err := os.Stat(spf); err != nil {
+			if _, err := s.statFile(spf); err != nil {
 				continue
 			}
 
 			// unmarshal the environment override over the base
-			if err := s.readOverrideFile(spf); err != nil {
+			if err := s.readOverrideFile(spf, SourceEnvFile); err != nil {
 				return false, err
 			}
 
@@ -498,7 +1097,7 @@ func (s *settings) searchForEnvOverrides(vars []string, searchPaths []string, fi
 	}
 
 	for _, v := range vars {
-		envName := os.Getenv(v)
+		envName, _, _, _ := s.getenv(v)
 
 		// detected an environment name
 		if envName != "" {
@@ -537,251 +1136,473 @@ func (s *settings) searchForEnvOverrides(vars []string, searchPaths []string, fi
 }
 
 func (s *settings) setFieldValue(fieldPath string, sVal string, override string) error {
+	return s.setFieldValues(fieldPath, []string{sVal}, override)
+}
+
+// setFieldValues parses and sets the field from one or more values; the
+// elements of every value are combined for slice and array fields (i.e. from
+// repeated arguments) while only the last value is used for any other field
+func (s *settings) setFieldValues(fieldPath string, sVals []string, override string) error {
+	fieldPath = s.resolvePath(fieldPath)
+
 	// ensure the field exists in the out object
-	if t, ok := s.fieldTypeMap[fieldPath]; ok {
-		// we found a match... ensure the type matches
-		var val interface{}
+	t, ok := s.fieldType(fieldPath)
+	if !ok {
+		// default field is not in the out struct
+		return SettingsFieldDoesNotExist(override, fieldPath)
+	}
 
-		switch t.Kind() {
-		case reflect.Array, reflect.Slice:
-			sVals := commaRE.Split(sVal, -1)
-			ov := s.findOutFieldValue(fieldPath)
-			st := ov.Type().Elem().Kind()
-			pv := reflect.MakeSlice(reflect.Indirect(ov).Type(), len(sVals), cap(sVals))
-
-			for i, sv := range sVals {
-				switch st {
-				case reflect.Bool:
-					v, err := strconv.ParseBool(sv)
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					pv.Index(i).Set(reflect.ValueOf(v))
-				case reflect.Int:
-					v, err := strconv.ParseInt(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := int(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Int8:
-					v, err := strconv.ParseInt(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := int8(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Int16:
-					v, err := strconv.ParseInt(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := int16(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Int32:
-					v, err := strconv.ParseInt(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := int32(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Int64:
-					v, err := strconv.ParseInt(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					pv.Index(i).Set(reflect.ValueOf(v))
-				case reflect.Uint:
-					v, err := strconv.ParseUint(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := uint(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Uint8:
-					v, err := strconv.ParseUint(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := uint8(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Uint16:
-					v, err := strconv.ParseUint(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := uint16(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Uint32:
-					v, err := strconv.ParseUint(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					iv := uint32(v)
-					pv.Index(i).Set(reflect.ValueOf(iv))
-				case reflect.Uint64:
-					v, err := strconv.ParseUint(sv, 0, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					pv.Index(i).Set(reflect.ValueOf(v))
-				case reflect.Float32:
-					v, err := strconv.ParseFloat(sv, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					fv := float32(v)
-					pv.Index(i).Set(reflect.ValueOf(fv))
-				case reflect.Float64:
-					v, err := strconv.ParseFloat(sv, ov.Type().Elem().Bits())
-					if err != nil {
-						return SettingsFieldSetError(fieldPath, t.Kind(), err)
-					}
-					pv.Index(i).Set(reflect.ValueOf(v))
-				case reflect.String:
-					pv.Index(i).Set(reflect.ValueOf(sv))
-				default:
-					// complex64, complex128, chan, func, interface, map, ptr, struct and unsafeptr
-					return SettingsFieldSetError(
-						fieldPath,
-						t.Kind(),
-						errors.New("unsupported field type"))
-				}
+	// empty values clear the field
+	var val reflect.Value
+	var err error
+	if s.emptyValues == EmptyClear && len(sVals) == 1 && sVals[0] == "" {
+		val = reflect.Zero(t)
+	} else {
+		val, err = parseValueList(t, sVals, s.valueFormat(fieldPath))
+	}
+
+	if err != nil {
+		return SettingsFieldSetError(fieldPath, t.Kind(), err)
+	}
+
+	// values are added to the end of the current slice rather than replacing it
+	if s.appendSlices && t.Kind() == reflect.Slice && !val.IsZero() {
+		if cur := s.findOutFieldValue(fieldPath); cur.IsValid() && cur.Len() > 0 {
+			combined := reflect.MakeSlice(t, 0, cur.Len()+val.Len())
+			val = reflect.AppendSlice(reflect.AppendSlice(combined, cur), val)
+		}
+	}
+
+	// find the field within the out struct and set it (if we can)
+	if err := s.setOutFieldValue(fieldPath, val); err != nil {
+		if errors.Is(err, errUnsettable) {
+			// unable to set the value
+			return SettingsFieldSetError(fieldPath, t.Kind())
+		}
+
+		return SettingsFieldSetError(fieldPath, t.Kind(), err)
+	}
+
+	return nil
+}
+
+// valueFormat returns the layout and list separator for the field
+func (s *settings) valueFormat(fieldPath string) valueFormat {
+	f := valueFormat{sep: s.listSeparator}
+	if s.plan != nil {
+		f.layout = s.plan.layouts[fieldPath]
+		if sep, ok := s.plan.separators[fieldPath]; ok {
+			f.sep = sep
+		}
+	}
+
+	return f
+}
+
+// parseValueList converts one or more strings into a value of the specified
+// type; the elements of every string are combined for slices and arrays while
+// only the last string is used for any other type
+func parseValueList(t reflect.Type, sVals []string, f valueFormat) (reflect.Value, error) {
+	sVal := sVals[len(sVals)-1]
+
+	if len(sVals) == 1 && isJSON(t, sVal) {
+		ptr := reflect.New(t)
+		err := json.Unmarshal([]byte(sVal), ptr.Interface())
+		return ptr.Elem(), err
+	}
+
+	// text values (i.e. net.IP) are parsed as a whole rather than per element
+	if !isList(t) {
+		return parseValue(t, sVal, f)
+	}
+
+	elems := []string{}
+	for _, sv := range sVals {
+		e, err := splitList(sv, f.separator())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		elems = append(elems, e...)
+	}
+
+	return parseValues(t, elems, f)
+}
+
+// valueFormat describes how strings are converted to field values
+type valueFormat struct {
+	layout string
+	sep    string
+}
+
+// separator returns the list separator (a comma unless otherwise specified)
+func (f valueFormat) separator() string {
+	if f.sep == "" {
+		return ","
+	}
+
+	return f.sep
+}
+
+// splitList splits a list into elements on the separator and trims the space
+// around each element; as with CSV, an element can be enclosed in double
+// quotes to include the separator (with "" for a literal double quote)
+func splitList(sVal string, sep string) ([]string, error) {
+	elems := []string{}
+
+	for {
+		rest := strings.TrimLeft(sVal, " \t")
+		if !strings.HasPrefix(rest, `"`) {
+			elem, after, found := strings.Cut(sVal, sep)
+			elems = append(elems, strings.TrimSpace(elem))
+			if !found {
+				return elems, nil
+			}
+
+			sVal = after
+			continue
+		}
+
+		// read the quoted element up to the closing quote
+		var b strings.Builder
+		rest = rest[1:]
+		for {
+			q := strings.Index(rest, `"`)
+			if q < 0 {
+				return nil, fmt.Errorf("missing closing quote in %q", sVal)
 			}
 
-			val = pv.Interface()
+			b.WriteString(rest[:q])
+			rest = rest[q+1:]
+			if !strings.HasPrefix(rest, `"`) {
+				break
+			}
+
+			b.WriteString(`"`)
+			rest = rest[1:]
+		}
+
+		elems = append(elems, b.String())
+
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return elems, nil
+		}
+
+		if !strings.HasPrefix(rest, sep) {
+			return nil, fmt.Errorf("unexpected text after quoted element in %q", sVal)
+		}
+
+		sVal = rest[len(sep):]
+	}
+}
+
+// parseValue converts a string into a value of the specified type; durations
+// use time.ParseDuration, times use the layout (RFC3339 by default) and types
+// implementing encoding.TextUnmarshaler use UnmarshalText
+func parseValue(t reflect.Type, sVal string, f valueFormat) (reflect.Value, error) {
+	// pointers are allocated for the parsed value
+	if t.Kind() == reflect.Ptr {
+		ev, err := parseValue(t.Elem(), sVal, f)
+		if err != nil {
+			return ev, err
+		}
+
+		pv := reflect.New(t.Elem())
+		pv.Elem().Set(ev)
+
+		return pv, nil
+	}
+
+	pv := reflect.New(t)
+	v := pv.Elem()
+
+	switch {
+	case t == durationType:
+		d, err := time.ParseDuration(sVal)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+	case t == timeType:
+		layout := f.layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+
+		tv, err := time.Parse(layout, sVal)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.ValueOf(tv))
+	case isText(t):
+		if err := pv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(sVal)); err != nil {
+			return v, err
+		}
+	default:
+		switch t.Kind() {
 		case reflect.Bool:
-			pv, err := strconv.ParseBool(sVal)
+			bv, err := strconv.ParseBool(sVal)
 			if err != nil {
-				return SettingsFieldSetError(fieldPath, t.Kind(), err)
+				return v, err
 			}
-			val = pv
-		case reflect.Int:
-			pv, err := strconv.ParseInt(sVal, 0, t.Bits())
+			v.SetBool(bv)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			iv, err := strconv.ParseInt(sVal, 0, t.Bits())
 			if err != nil {
-				return SettingsFieldSetError(fieldPath, t.Kind(), err)
+				return v, err
 			}
-			val = int(pv)
-		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			pv, err := strconv.ParseInt(sVal, 0, t.Bits())
+			v.SetInt(iv)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			uv, err := strconv.ParseUint(sVal, 0, t.Bits())
 			if err != nil {
-				return SettingsFieldSetError(fieldPath, t.Kind(), err)
-			}
-
-			switch t.Bits() {
-			case 8:
-				val = int8(pv)
-			case 16:
-				val = int16(pv)
-			case 32:
-				val = int32(pv)
-			default:
-				val = pv
+				return v, err
 			}
-		case reflect.Uint:
-			pv, err := strconv.ParseInt(sVal, 0, t.Bits())
+			v.SetUint(uv)
+		case reflect.Float32, reflect.Float64:
+			fv, err := strconv.ParseFloat(sVal, t.Bits())
 			if err != nil {
-				return SettingsFieldSetError(fieldPath, t.Kind(), err)
+				return v, err
 			}
-			val = uint(pv)
-		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			pv, err := strconv.ParseUint(sVal, 0, t.Bits())
+			v.SetFloat(fv)
+		case reflect.String:
+			v.SetString(sVal)
+		case reflect.Map:
+			// maps are provided as separated (comma by default) key=value pairs
+			pairs, err := splitList(sVal, f.separator())
 			if err != nil {
-				return SettingsFieldSetError(fieldPath, t.Kind(), err)
+				return v, err
 			}
 
-			switch t.Bits() {
-			case 8:
-				val = uint8(pv)
-			case 16:
-				val = uint16(pv)
-			case 32:
-				val = uint32(pv)
-			default:
-				val = pv
-			}
-		case reflect.Float32, reflect.Float64:
-			pv, err := strconv.ParseFloat(sVal, t.Bits())
-			if err != nil {
-				return SettingsFieldSetError(fieldPath, t.Kind(), err)
-			}
+			m := reflect.MakeMap(t)
+			for _, pair := range pairs {
+				k, ev, ok := strings.Cut(pair, "=")
+				if !ok {
+					return v, fmt.Errorf("expected key=value but found %q", pair)
+				}
 
-			switch t.Bits() {
-			case 32:
-				val = float32(pv)
-			default:
-				val = pv
-			}
-		case reflect.String:
-			val = sVal
-		default:
-			if t == timeType {
-				dv, err := time.Parse(time.RFC3339, sVal)
+				kv, err := parseValue(t.Key(), k, f)
 				if err != nil {
-					return SettingsFieldSetError(fieldPath, t.Kind(), err)
+					return v, err
 				}
-				val = dv
-				break
+
+				mv, err := parseValue(t.Elem(), ev, f)
+				if err != nil {
+					return v, err
+				}
+
+				m.SetMapIndex(kv, mv)
 			}
+			v.Set(m)
+		default:
+			// complex64, complex128, chan, func, interface, struct and unsafeptr
+			return v, errors.New("unsupported field type")
+		}
+	}
 
-			// complex64, complex128, chan, func, interface, map, ptr, struct and unsafeptr
-			return SettingsFieldSetError(
-				fieldPath,
-				t.Kind(),
-				errors.New("unsupported field type"))
+	return v, nil
+}
+
+// parseValues converts each string into an element of the slice or array type
+func parseValues(t reflect.Type, sVals []string, f valueFormat) (reflect.Value, error) {
+	var v reflect.Value
+	if t.Kind() == reflect.Array {
+		if len(sVals) > t.Len() {
+			return v, fmt.Errorf("too many values (%d) for array of length %d", len(sVals), t.Len())
 		}
+		v = reflect.New(t).Elem()
+	} else {
+		v = reflect.MakeSlice(t, len(sVals), len(sVals))
+	}
+
+	for i, sv := range sVals {
+		ev, err := parseValue(t.Elem(), sv, f)
+		if err != nil {
+			return v, err
+		}
+		v.Index(i).Set(ev)
+	}
+
+	return v, nil
+}
+
+// isLeaf returns true when the type is set as a whole rather than as a
+// struct with nested fields
+func isLeaf(t reflect.Type) bool {
+	t = derefType(t)
+	return t.Kind() != reflect.Struct || t == timeType || isText(t)
+}
+
+// derefType returns the type that a pointer type (or pointer to pointer) points to
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// isText returns true when a pointer to the type implements encoding.TextUnmarshaler
+func isText(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// isJSON returns true when the value is a JSON array literal for a slice or
+// array type (i.e. [{"host":"a"}] for a []Backend field), or a JSON object
+// literal for a map or struct type
+func isJSON(t reflect.Type, sVal string) bool {
+	sVal = strings.TrimSpace(sVal)
+	if !json.Valid([]byte(sVal)) {
+		return false
+	}
+
+	switch derefType(t).Kind() {
+	case reflect.Array, reflect.Slice:
+		return !isText(derefType(t)) && strings.HasPrefix(sVal, "[")
+	case reflect.Map:
+		return strings.HasPrefix(sVal, "{")
+	}
+
+	return !isLeaf(t) && strings.HasPrefix(sVal, "{")
+}
+
+// isBool returns true when the type (or the type it points to) is a bool
+func isBool(t reflect.Type) bool {
+	return derefType(t).Kind() == reflect.Bool
+}
+
+// isList returns true when the type is a slice or array that is set from a
+// list of elements
+func isList(t reflect.Type) bool {
+	return (t.Kind() == reflect.Array || t.Kind() == reflect.Slice) && !isText(t)
+}
+
+// hasEntries returns true when entries of the type can be set individually
+// via a keyed or indexed path (maps, slices and arrays)
+func hasEntries(t reflect.Type) bool {
+	t = derefType(t)
+
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Array, reflect.Slice:
+		return !isText(t)
+	}
+
+	return false
+}
 
-		// don't try to set if there's no value to set
-		if reflect.Zero(t) == val || val == nil {
-			return nil
+// entryPath converts the parts of an arg or environment variable name that
+// follow the name of a map or slice field (i.e. ["1", "port"] for
+// --backends[1].port) into a path relative to the field. Struct field names
+// are matched regardless of case and, for environment variables, may span
+// several parts (i.e. CERT_FILE for CertFile); map keys from environment
+// variables are lower cased.
+func entryPath(t reflect.Type, parts []string, sep string, env bool) (string, bool) {
+	t = derefType(t)
+	if len(parts) == 0 {
+		return "", isLeaf(t)
+	}
+
+	var name string
+	var rest []string
+
+	switch {
+	case t.Kind() == reflect.Map:
+		// the remaining parts are the key when the entry is a leaf
+		n := 1
+		if isLeaf(t.Elem()) {
+			n = len(parts)
+		}
+
+		name, rest = strings.Join(parts[:n], sep), parts[n:]
+		if env {
+			name = strings.ToLower(name)
+		}
+	case hasEntries(t):
+		if i, err := strconv.Atoi(parts[0]); err != nil || i < 0 {
+			return "", false
 		}
 
-		// find the field within the out struct and set it (if we can)
-		v := s.findOutFieldValue(fieldPath)
-		if v.CanSet() {
-			dv := reflect.ValueOf(val)
-			v.Set(dv)
-			return nil
+		name, rest = parts[0], parts[1:]
+	case !isLeaf(t):
+		n := 1
+		if env {
+			n = len(parts)
 		}
 
-		// unable to set the value
-		return SettingsFieldSetError(fieldPath, t.Kind())
+		for ; n > 0; n-- {
+			fn := strings.Join(parts[:n], "")
+			f, ok := t.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, fn) })
+			if !ok || !f.IsExported() {
+				continue
+			}
+
+			if sub, ok := entryPath(f.Type, parts[n:], sep, env); ok {
+				return joinPath(f.Name, sub), true
+			}
+		}
+
+		return "", false
+	default:
+		return "", false
+	}
+
+	sub, ok := entryPath(t.Elem(), rest, sep, env)
+	if !ok {
+		return "", false
 	}
 
-	// default field is not in the out struct
-	return SettingsFieldDoesNotExist(override, fieldPath)
+	return joinPath(name, sub), true
 }
 
-func (s *settings) unmarshalFile(path string, out interface{}) error {
+// joinPath joins the field names, ignoring any that are empty
+func joinPath(names ...string) string {
+	parts := []string{}
+	for _, n := range names {
+		if n != "" {
+			parts = append(parts, n)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func (s *settings) loadFile(fsys fs.FS, path string) (string, []byte, error) {
 	t, err := s.determineFileType(path)
 	if err != nil {
 		// unable to determine base settings file type
-		return err
+		return t, nil, err
 	}
 
-	in, err := os.ReadFile(path)
+	in, err := fs.ReadFile(fsys, fsPath(fsys, path))
 	if err != nil {
 		// unable to read the file
-		return SettingsFileReadError(path, err.Error())
+		return t, nil, fileReadError(path, err)
 	}
 
-	// unmarshal YAML
-	if t == "yaml" {
-		if err := yaml.Unmarshal(in, out); err != nil {
-			// unable to unmarshal as YAML
-			return SettingsFileParseError(path, err.Error())
-		}
+	return t, in, nil
+}
 
-		return nil
+func (s *settings) unmarshalData(path string, t string, in []byte, out interface{}) error {
+	f, ok := formatByName(t)
+	if !ok {
+		return SettingsFileTypeError(path, filepath.Ext(path))
 	}
 
-	// unmarshal JSON
-	if t == "json" {
-		if err := json.Unmarshal(in, out); err != nil {
-			// unable to unmarshal as JSON
-			return SettingsFileParseError(path, err.Error())
-		}
+	if err := f.decode(in, out); err != nil {
+		// unable to unmarshal the file
+		return fileParseError(path, in, err)
 	}
 
 	return nil
 }
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
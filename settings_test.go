@@ -1,6 +1,12 @@
 package settings
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -788,6 +794,13 @@ func Test_settings_cleanArgValue(t *testing.T) {
 			},
 			"value",
 		},
+		{
+			"should return an empty value for a lone equal",
+			args{
+				"=",
+			},
+			"",
+		},
 		{
 			"should not remove equal in the middle...",
 			args{
@@ -905,10 +918,18 @@ func Test_settings_determineFileType(t *testing.T) {
 			false,
 		},
 		{
-			"should error when unsupported",
+			"should properly detect toml",
 			args{
 				path: "./config.toml",
 			},
+			"toml",
+			false,
+		},
+		{
+			"should error when unsupported",
+			args{
+				path: "./config.ini",
+			},
 			"",
 			true,
 		},
@@ -1058,7 +1079,7 @@ func Test_settings_readOverrideFile(t *testing.T) {
 				out: &testConfig{},
 			}
 
-			if err := s.readOverrideFile(tt.path(t)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			if err := s.readOverrideFile(tt.path(t), SourceArgsFile); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 				t.Fatalf("settings.readOverrideFile() expected error containing %q, got %v", tt.wantErr, err)
 			}
 		})
@@ -1555,7 +1576,7 @@ func Test_settings_findOutFieldValue(t *testing.T) {
 	}
 }
 
-func Test_settings_unmarshalFile(t *testing.T) {
+func Test_settings_loadFile(t *testing.T) {
 	type testConfig struct {
 		Name string `json:"name" yaml:"name"`
 	}
@@ -1563,17 +1584,26 @@ func Test_settings_unmarshalFile(t *testing.T) {
 	s := &settings{}
 	dir := t.TempDir()
 
+	read := func(path string, out interface{}) error {
+		ft, in, err := s.loadFile(OSFS(), path)
+		if err != nil {
+			return err
+		}
+
+		return s.unmarshalData(path, ft, in, out)
+	}
+
 	jsonPath := filepath.Join(dir, "config.json")
 	if err := os.WriteFile(jsonPath, []byte(`{"name":"json config"}`), 0600); err != nil {
 		t.Fatalf("unable to setup json config: %v", err)
 	}
 
 	cfg := &testConfig{}
-	if err := s.unmarshalFile(jsonPath, cfg); err != nil {
-		t.Fatalf("settings.unmarshalFile() unexpected error reading json: %v", err)
+	if err := read(jsonPath, cfg); err != nil {
+		t.Fatalf("settingread() unexpected error reading json: %v", err)
 	}
 	if cfg.Name != "json config" {
-		t.Fatalf("settings.unmarshalFile() json config Name = %s, want json config", cfg.Name)
+		t.Fatalf("settingread() json config Name = %s, want json config", cfg.Name)
 	}
 
 	unreadableDir := filepath.Join(dir, "unreadable.yaml")
@@ -1581,12 +1611,12 @@ func Test_settings_unmarshalFile(t *testing.T) {
 		t.Fatalf("unable to create unreadable directory: %v", err)
 	}
 
-	if err := s.unmarshalFile(unreadableDir, &testConfig{}); err == nil || !strings.Contains(err.Error(), "unable to read settings file") {
-		t.Fatalf("settings.unmarshalFile() expected read error for directory path, got %v", err)
+	if err := read(unreadableDir, &testConfig{}); err == nil || !strings.Contains(err.Error(), "unable to read settings file") {
+		t.Fatalf("settingread() expected read error for directory path, got %v", err)
 	}
 
-	if err := s.unmarshalFile(filepath.Join(dir, "config.toml"), &testConfig{}); err == nil || !strings.Contains(err.Error(), "unrecognized settings file extension") {
-		t.Fatalf("settings.unmarshalFile() expected unsupported file type error, got %v", err)
+	if err := read(filepath.Join(dir, "config.ini"), &testConfig{}); err == nil || !strings.Contains(err.Error(), "unrecognized settings file extension") {
+		t.Fatalf("settingread() expected unsupported file type error, got %v", err)
 	}
 
 	badYAML := filepath.Join(dir, "bad.yaml")
@@ -1594,8 +1624,8 @@ func Test_settings_unmarshalFile(t *testing.T) {
 		t.Fatalf("unable to write bad yaml: %v", err)
 	}
 
-	if err := s.unmarshalFile(badYAML, &testConfig{}); err == nil || !strings.Contains(err.Error(), "unable to parse") {
-		t.Fatalf("settings.unmarshalFile() expected parse error for invalid yaml, got %v", err)
+	if err := read(badYAML, &testConfig{}); err == nil || !strings.Contains(err.Error(), "unable to parse") {
+		t.Fatalf("settingread() expected parse error for invalid yaml, got %v", err)
 	}
 }
 
@@ -1608,23 +1638,19 @@ func TestGather_EndToEnd(t *testing.T) {
 		Tags    []string
 	}
 
-	origArgs := os.Args
-	defer func() {
-		os.Args = origArgs
-		os.Clearenv()
-	}()
-
-	os.Args = []string{
-		"cmd",
-		"--config-file", "./tests/config.simple.yml",
-		"--name", "cli name",
-	}
-
-	os.Setenv("GO_ENV", "pattern")
-	os.Setenv("APP_NAME", "env name")
-	os.Setenv("VERSION", "2.0")
+	t.Parallel()
 
 	opts := Options().
+		SetArgs([]string{
+			"cmd",
+			"--config-file", "./tests/config.simple.yml",
+			"--name", "cli name",
+		}).
+		SetEnv(map[string]string{
+			"GO_ENV":   "pattern",
+			"APP_NAME": "env name",
+			"VERSION":  "2.0",
+		}).
 		SetBasePath("./tests/simple.yaml").
 		SetDefaultsMap(map[string]interface{}{
 			"Port": 8080,
@@ -1731,24 +1757,1100 @@ func TestGather_Errors(t *testing.T) {
 		},
 	}
 
+	t.Parallel()
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			origArgs := os.Args
-			os.Args = tt.args
-			defer func() {
-				os.Args = origArgs
-				os.Clearenv()
-			}()
-
-			for k, v := range tt.env {
-				os.Setenv(k, v)
-			}
+			t.Parallel()
 
 			cfg := &testConfig{}
-			err := Gather(tt.opts, cfg)
+			err := Gather(tt.opts.SetArgs(tt.args).SetEnv(tt.env), cfg)
 			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
 				t.Fatalf("Gather() expected error containing %q, got %v", tt.wantError, err)
 			}
 		})
 	}
 }
+
+func TestGather_Hermetic(t *testing.T) {
+	type testConfig struct {
+		Name string
+		Port int
+	}
+
+	// values in the process environment and arguments must be ignored
+	origArgs := os.Args
+	t.Cleanup(func() {
+		os.Args = origArgs
+	})
+
+	os.Args = []string{"cmd", "--name", "process name"}
+	t.Setenv("PORT", "1")
+
+	opts := Options().
+		SetArg("--name", "Name").
+		SetVar("PORT", "Port")
+
+	cfg := &testConfig{}
+	if err := Gather(opts.SetArgs([]string{}).SetEnv(nil), cfg); err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg, &testConfig{}) {
+		t.Errorf("Gather() = %+v, want zero values", cfg)
+	}
+
+	cfg = &testConfig{}
+	err := Gather(opts.
+		SetArgs([]string{"--name", "injected name"}).
+		SetEnv(map[string]string{"PORT": "3000"}), cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	if want := (&testConfig{Name: "injected name", Port: 3000}); !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Gather() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestGather_AggregatesErrors(t *testing.T) {
+	type testConfig struct {
+		Count   int
+		Enabled bool
+		Port    uint
+		Name    string
+	}
+
+	opts := Options().
+		SetArgs([]string{"cmd", "--count", "nan", "--name", "cli name"}).
+		SetEnv(map[string]string{
+			"ENABLED": "not-a-bool",
+			"PORT":    "abc",
+		}).
+		SetDefaultsMap(map[string]interface{}{
+			"Unknown": true,
+		}).
+		SetArgsMap(map[string]string{
+			"--count": "Count",
+			"--name":  "Name",
+		}).
+		SetVarsMap(map[string]string{
+			"ENABLED": "Enabled",
+			"PORT":    "Port",
+		})
+
+	cfg := &testConfig{}
+	err := Gather(opts, cfg)
+
+	var errs SettingsErrors
+	if !errors.As(err, &errs) || len(errs) != 4 {
+		t.Fatalf("Gather() expected 4 aggregated errors, got %v", err)
+	}
+
+	for _, want := range []string{
+		"(DefaultsMap) does not exist in the target out struct: Unknown",
+		"Count (type: int)",
+		"(source: Args --count)",
+		"(source: Vars ENABLED)",
+		"(source: Vars PORT)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Gather() error = %v, missing %q", err, want)
+		}
+	}
+
+	// valid values are still applied around the failures
+	if cfg.Name != "cli name" {
+		t.Errorf("Gather() Name = %s, want cli name", cfg.Name)
+	}
+}
+
+func TestGather_ErrorKinds(t *testing.T) {
+	type testConfig struct {
+		Name string `yaml:"name"`
+	}
+
+	err := Gather(Options().SetBasePath("./does/not/exist.yml"), &testConfig{})
+	if !errors.Is(err, ErrFileNotFound) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Gather() expected ErrFileNotFound for missing base file, got %v", err)
+	}
+
+	err = Gather(Options().SetBasePath("./tests/broken.json"), &testConfig{})
+	if !errors.Is(err, ErrFileParse) || errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("Gather() expected ErrFileParse for broken base file, got %v", err)
+	}
+
+	var se SettingsError
+	if !errors.As(err, &se) || se.Path != "./tests/broken.json" {
+		t.Fatalf("Gather() expected SettingsError with Path, got %+v", se)
+	}
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Gather() expected to unwrap *json.SyntaxError from %v", err)
+	}
+}
+
+func TestGather_TOML(t *testing.T) {
+	type testConfig struct {
+		Name    string    `toml:"name"`
+		Created time.Time `toml:"created"`
+		Version string    `toml:"version"`
+		Data    struct {
+			Host string `toml:"host"`
+			Port int    `toml:"port"`
+		} `toml:"data"`
+	}
+
+	opts := Options().
+		SetArgs([]string{"cmd", "--config-file", "./tests/override.toml"}).
+		SetEnv(map[string]string{"GO_ENV": "env"}).
+		SetBasePath("./tests/simple.toml").
+		SetArgsFileOverride("--config-file").
+		SetEnvOverride("GO_ENV").
+		SetEnvSearchPaths("./tests").
+		SetEnvSearchPattern("config.%s")
+
+	cfg := &testConfig{}
+	r, err := GatherWithReport(opts, cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	if cfg.Name != "example-toml-override" {
+		t.Errorf("Gather() Name = %s, want example-toml-override", cfg.Name)
+	}
+
+	expectedCreated := time.Date(2021, time.February, 16, 0, 0, 0, 0, time.UTC)
+	if !cfg.Created.Equal(expectedCreated) {
+		t.Errorf("Gather() Created = %v, want %v", cfg.Created, expectedCreated)
+	}
+
+	if cfg.Data.Host != "localhost" || cfg.Data.Port != 5432 {
+		t.Errorf("Gather() Data = %+v, want localhost:5432", cfg.Data)
+	}
+
+	if fr, _ := r.Field("Data.Port"); fr.Source != SourceEnvFile || fr.Origin != "tests/config.env.toml" {
+		t.Errorf("Report.Field(Data.Port) = %+v", fr)
+	}
+
+	if fr, _ := r.Field("Name"); fr.Source != SourceArgsFile {
+		t.Errorf("Report.Field(Name) = %+v", fr)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	type testConfig struct {
+		Name string `yaml:"name"`
+		Port int    `env:"PORT"`
+	}
+
+	t.Parallel()
+
+	opts := Options().
+		SetEnv(map[string]string{"PORT": "3000"}).
+		SetBasePath("./tests/simple.yaml")
+
+	cfg, err := Load[testConfig](opts)
+	if err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+
+	if want := (testConfig{Name: "example", Port: 3000}); cfg != want {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+
+	if _, err := Load[string](opts); !errors.Is(err, ErrTypeDiscovery) {
+		t.Errorf("Load() error = %v, want ErrTypeDiscovery", err)
+	}
+}
+
+func TestMustLoad(t *testing.T) {
+	type testConfig struct {
+		Name string `yaml:"name"`
+	}
+
+	t.Parallel()
+
+	if cfg := MustLoad[testConfig](Options().SetBasePath("./tests/simple.yaml")); cfg.Name != "example" {
+		t.Errorf("MustLoad() Name = %s, want example", cfg.Name)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("MustLoad() expected panic for missing base file")
+		}
+	}()
+
+	MustLoad[testConfig](Options().SetBasePath("./does/not/exist.yml"))
+}
+
+func TestGather_DefaultTags(t *testing.T) {
+	type testConfig struct {
+		Name    string    `yaml:"name" default:"unnamed"`
+		Version string    `yaml:"version" default:"0.0"`
+		Port    int64     `default:"8080"`
+		Tags    []string  `default:"a,b"`
+		Updated time.Time `default:"2021-01-02T03:04:05Z"`
+		Server  struct {
+			Address string `default:":3080"`
+		}
+	}
+
+	t.Parallel()
+
+	opts := Options().
+		SetArgs([]string{"--port", "9000"}).
+		SetArg("--port", "Port").
+		SetBasePath("./tests/simple.yaml")
+
+	cfg := &testConfig{}
+	r, err := GatherWithReport(opts, cfg)
+	if err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	if cfg.Name != "example" || cfg.Port != 9000 || cfg.Server.Address != ":3080" {
+		t.Errorf("Gather() = %+v", cfg)
+	}
+
+	if !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) {
+		t.Errorf("Gather() Tags = %v, want [a b]", cfg.Tags)
+	}
+
+	if want := time.Date(2021, time.January, 2, 3, 4, 5, 0, time.UTC); !cfg.Updated.Equal(want) {
+		t.Errorf("Gather() Updated = %v, want %v", cfg.Updated, want)
+	}
+
+	if fr, _ := r.Field("Server.Address"); fr.Source != SourceDefaultTag {
+		t.Errorf("Report.Field(Server.Address) = %+v", fr)
+	}
+
+	if fr, _ := r.Field("Port"); fr.Source != SourceArgs || len(fr.Overridden) != 1 || fr.Overridden[0].Source != SourceDefaultTag {
+		t.Errorf("Report.Field(Port) = %+v", fr)
+	}
+
+	type badConfig struct {
+		Count int `default:"ten"`
+	}
+
+	var se SettingsError
+	err = Gather(Options(), &badConfig{})
+	if !errors.Is(err, ErrFieldSet) || !errors.As(err, &se) || se.Source != SourceDefaultTag || se.Value != "ten" {
+		t.Errorf("Gather() expected field set error from default tag, got %v", err)
+	}
+}
+
+func TestGather_Required(t *testing.T) {
+	type testConfig struct {
+		Name  string `yaml:"name" required:"true"`
+		Count int    `yaml:"count" required:"true"`
+		Data  struct {
+			Host    string `required:"true"`
+			Enabled bool
+		}
+		Optional string         `required:"false"`
+		Limits   map[string]int `yaml:"limits"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("name: example\ncount: 0\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	t.Run("should list every required field that was not set", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetRequired("Data.Enabled", "Data.Missing")
+
+		err := Gather(opts, &testConfig{})
+		if !errors.Is(err, ErrFieldRequired) || !errors.Is(err, ErrFieldDoesNotExist) {
+			t.Fatalf("Gather() expected required field errors, got %v", err)
+		}
+
+		var errs SettingsErrors
+		if !errors.As(err, &errs) || len(errs) != 3 {
+			t.Fatalf("Gather() expected 3 errors, got %v", err)
+		}
+
+		fields := []string{}
+		for _, e := range errs {
+			fields = append(fields, e.(SettingsError).Field)
+		}
+		if want := []string{"Data.Enabled", "Data.Host", "Data.Missing"}; !reflect.DeepEqual(fields, want) {
+			t.Errorf("Gather() required fields = %v, want %v", fields, want)
+		}
+	})
+
+	t.Run("should count explicit zero values as set", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetArgs([]string{"--enabled=false"}).
+			SetArg("--enabled", "Data.Enabled").
+			SetEnv(map[string]string{"DATA_HOST": "db.internal"}).
+			SetVar("DATA_HOST", "Data.Host").
+			SetRequired("Data.Enabled")
+
+		cfg := &testConfig{}
+		if err := Gather(opts, cfg); err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		if cfg.Count != 0 || cfg.Data.Enabled || cfg.Data.Host != "db.internal" {
+			t.Errorf("Gather() = %+v", cfg)
+		}
+	})
+
+	t.Run("should require map entries that were not set", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetArgs([]string{}).
+			SetEnv(map[string]string{"DATA_HOST": "db.internal"}).
+			SetVar("DATA_HOST", "Data.Host").
+			SetRequired("Limits.orders")
+
+		err := Gather(opts, &testConfig{})
+		if !errors.Is(err, ErrFieldRequired) || errors.Is(err, ErrFieldDoesNotExist) {
+			t.Errorf("Gather() expected required field error, got %v", err)
+		}
+
+		opts = opts.SetEnv(map[string]string{"DATA_HOST": "db.internal", "LIMITS_ORDERS": "10"}).
+			SetVar("LIMITS_ORDERS", "Limits.orders")
+		if err := Gather(opts, &testConfig{}); err != nil {
+			t.Errorf("Gather() unexpected error = %v", err)
+		}
+	})
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+
+	return nil
+}
+
+func TestGather_TextValues(t *testing.T) {
+	type testConfig struct {
+		Timeout  time.Duration
+		Backoff  []time.Duration
+		IP       net.IP
+		Addr     netip.Addr
+		Peers    []netip.Addr
+		Max      big.Int
+		Level    testLevel
+		Date     time.Time   `layout:"2006-01-02"`
+		Holidays []time.Time `layout:"2006-01-02"`
+		Created  time.Time
+		Port     uint16
+	}
+
+	t.Parallel()
+
+	opts := Options().
+		SetEnv(map[string]string{
+			"TIMEOUT":  "5s",
+			"BACKOFF":  "100ms, 1s,1m",
+			"IP":       "10.0.0.1",
+			"ADDR":     "::1",
+			"PEERS":    "10.0.0.2,10.0.0.3",
+			"MAX":      "123456789012345678901234567890",
+			"LEVEL":    "info",
+			"DATE":     "2024-03-01",
+			"HOLIDAYS": "2024-12-25,2025-01-01",
+			"CREATED":  "2024-03-01T10:00:00Z",
+			"PORT":     "8080",
+		}).
+		SetVarsMap(map[string]string{
+			"TIMEOUT":  "Timeout",
+			"BACKOFF":  "Backoff",
+			"IP":       "IP",
+			"ADDR":     "Addr",
+			"PEERS":    "Peers",
+			"MAX":      "Max",
+			"LEVEL":    "Level",
+			"DATE":     "Date",
+			"HOLIDAYS": "Holidays",
+			"CREATED":  "Created",
+			"PORT":     "Port",
+		})
+
+	cfg := &testConfig{}
+	if err := Gather(opts, cfg); err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Gather() Timeout = %v, want 5s", cfg.Timeout)
+	}
+	if want := []time.Duration{100 * time.Millisecond, time.Second, time.Minute}; !reflect.DeepEqual(cfg.Backoff, want) {
+		t.Errorf("Gather() Backoff = %v, want %v", cfg.Backoff, want)
+	}
+	if !cfg.IP.Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Gather() IP = %v, want 10.0.0.1", cfg.IP)
+	}
+	if cfg.Addr != netip.MustParseAddr("::1") {
+		t.Errorf("Gather() Addr = %v, want ::1", cfg.Addr)
+	}
+	if want := []netip.Addr{netip.MustParseAddr("10.0.0.2"), netip.MustParseAddr("10.0.0.3")}; !reflect.DeepEqual(cfg.Peers, want) {
+		t.Errorf("Gather() Peers = %v, want %v", cfg.Peers, want)
+	}
+	if cfg.Max.String() != "123456789012345678901234567890" {
+		t.Errorf("Gather() Max = %v", cfg.Max.String())
+	}
+	if cfg.Level != 1 {
+		t.Errorf("Gather() Level = %v, want 1", cfg.Level)
+	}
+	if want := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC); !cfg.Date.Equal(want) {
+		t.Errorf("Gather() Date = %v, want %v", cfg.Date, want)
+	}
+	if len(cfg.Holidays) != 2 || !cfg.Holidays[1].Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Gather() Holidays = %v", cfg.Holidays)
+	}
+	if want := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC); !cfg.Created.Equal(want) {
+		t.Errorf("Gather() Created = %v, want %v", cfg.Created, want)
+	}
+	if cfg.Port != 8080 {
+		t.Errorf("Gather() Port = %v, want 8080", cfg.Port)
+	}
+
+	tests := []struct {
+		field   string
+		value   string
+		wantErr string
+	}{
+		{"Timeout", "5", "missing unit"},
+		{"Level", "trace", "unknown level"},
+		{"Date", "2024-03-01T10:00:00Z", "extra text"},
+		{"Addr", "not-an-ip", "ParseAddr"},
+		{"Port", "-1", "invalid syntax"},
+	}
+	for _, tt := range tests {
+		err := Gather(
+			Options().SetEnv(map[string]string{"VALUE": tt.value}).SetVar("VALUE", tt.field),
+			&testConfig{})
+		if !errors.Is(err, ErrFieldSet) || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Gather() %s = %q error = %v, want %q", tt.field, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+type testTLSConfig struct {
+	CertFile string `json:"certFile" env:"TLS_CERT"`
+	Enabled  bool   `json:"enabled"`
+}
+
+type testCommon struct {
+	Host string `json:"host"`
+	Port int    `json:"port" arg:"--port"`
+}
+
+type testNode struct {
+	Name string
+	Next *testNode
+}
+
+func TestGather_PointerAndEmbedded(t *testing.T) {
+	type testConfig struct {
+		testCommon
+		TLS     *testTLSConfig `json:"tls"`
+		Metrics *struct {
+			Path string
+		} `json:"metrics"`
+		Name  *string `env:"NAME"`
+		Nodes testNode
+	}
+
+	t.Parallel()
+
+	t.Run("should leave nil pointers nil when nothing is set", func(t *testing.T) {
+		cfg := &testConfig{}
+		if err := Gather(Options().SetArgs([]string{}).SetEnv(nil), cfg); err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		if cfg.TLS != nil || cfg.Metrics != nil || cfg.Name != nil {
+			t.Errorf("Gather() = %+v, want nil pointers", cfg)
+		}
+	})
+
+	t.Run("should allocate pointers and resolve promoted fields", func(t *testing.T) {
+		opts := Options().
+			SetArgs([]string{"--port", "8080"}).
+			SetEnv(map[string]string{
+				"TLS_CERT": "/etc/cert.pem",
+				"HOST":     "db.internal",
+				"NAME":     "pointer name",
+				"NODE":     "first",
+			}).
+			SetVar("HOST", "Host").
+			SetVar("NODE", "Nodes.Name").
+			SetRequired("Host", "testCommon.Port")
+
+		cfg := &testConfig{}
+		r, err := GatherWithReport(opts, cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		if cfg.TLS == nil || cfg.TLS.CertFile != "/etc/cert.pem" {
+			t.Errorf("Gather() TLS = %+v", cfg.TLS)
+		}
+		if cfg.Metrics != nil {
+			t.Errorf("Gather() Metrics = %+v, want nil", cfg.Metrics)
+		}
+		if cfg.Port != 8080 || cfg.Host != "db.internal" || cfg.Nodes.Name != "first" {
+			t.Errorf("Gather() = %+v", cfg)
+		}
+		if cfg.Name == nil || *cfg.Name != "pointer name" {
+			t.Errorf("Gather() Name = %v", cfg.Name)
+		}
+
+		promoted, _ := r.Field("Port")
+		full, _ := r.Field("testCommon.Port")
+		if promoted.Source != SourceArgs || promoted.Path != full.Path {
+			t.Errorf("Report.Field(Port) = %+v, Report.Field(testCommon.Port) = %+v", promoted, full)
+		}
+	})
+
+	t.Run("should apply promoted fields from defaults and files", func(t *testing.T) {
+		basePath := filepath.Join(t.TempDir(), "base.json")
+		if err := os.WriteFile(basePath, []byte(`{"host": "file host", "tls": {"enabled": true}}`), 0o600); err != nil {
+			t.Fatalf("unable to write base file: %v", err)
+		}
+
+		opts := Options().
+			SetArgs([]string{}).
+			SetEnv(nil).
+			SetBasePath(basePath).
+			SetDefaultsMap(map[string]interface{}{
+				"Port":             3000,
+				"Metrics.Path":     "/metrics",
+				"testCommon.Host":  "default host",
+				"TLS.CertFile":     "/etc/default.pem",
+				"Nodes.Next.Name":  "not walked",
+				"Metrics.Unknown":  "missing",
+				"testCommon.Other": "missing",
+			})
+
+		err := Gather(opts, &testConfig{})
+		var errs SettingsErrors
+		if !errors.As(err, &errs) || len(errs) != 3 || !errors.Is(err, ErrFieldDoesNotExist) {
+			t.Fatalf("Gather() expected 3 field does not exist errors, got %v", err)
+		}
+
+		for _, e := range errs {
+			var se SettingsError
+			if !errors.As(e, &se) || se.Source != SourceDefaultsMap {
+				t.Errorf("Gather() error source = %s, want %s", se.Source, SourceDefaultsMap)
+			}
+		}
+
+		delete(opts.DefaultsMap, "Nodes.Next.Name")
+		delete(opts.DefaultsMap, "Metrics.Unknown")
+		delete(opts.DefaultsMap, "testCommon.Other")
+
+		cfg := &testConfig{}
+		r, err := GatherWithReport(opts, cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		if cfg.Port != 3000 || cfg.Host != "default host" || cfg.Metrics == nil || cfg.Metrics.Path != "/metrics" {
+			t.Errorf("Gather() = %+v", cfg)
+		}
+		if cfg.TLS == nil || !cfg.TLS.Enabled || cfg.TLS.CertFile != "/etc/default.pem" {
+			t.Errorf("Gather() TLS = %+v", cfg.TLS)
+		}
+
+		if fr, _ := r.Field("Host"); fr.Source != SourceDefaultsMap || len(fr.Overridden) != 1 || fr.Overridden[0].Source != SourceBaseFile {
+			t.Errorf("Report.Field(Host) = %+v", fr)
+		}
+		if fr, _ := r.Field("TLS.Enabled"); fr.Source != SourceBaseFile {
+			t.Errorf("Report.Field(TLS.Enabled) = %+v", fr)
+		}
+	})
+}
+
+type testService struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+func TestGather_Maps(t *testing.T) {
+	type testConfig struct {
+		Limits   map[string]int         `yaml:"limits" env:"LIMITS" arg:"--limits"`
+		Labels   map[string]string      `env:"LABELS"`
+		Services map[string]testService `yaml:"services"`
+		Shards   map[int]string
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("limits:\n  orders: 1\n  carts: 1\nservices:\n  orders:\n    host: orders.internal\n    port: 80\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	t.Run("should create and update map entries by path", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetDefaultsMap(map[string]interface{}{
+				"Limits.default":      100,
+				"Services.users.Port": 8080,
+			}).
+			SetArgs([]string{"--limits.carts=3", "--limits.users", "7"}).
+			SetEnv(map[string]string{
+				"LIMITS_ORDERS":   "10",
+				"LIMITS_USERS":    "5",
+				"LABELS":          "team=core, tier=1",
+				"ORDERS_PORT":     "8443",
+				"SHARD_TWO":       "replica",
+				"UNRELATED_LIMIT": "0",
+			}).
+			SetVar("ORDERS_PORT", "Services.orders.Port").
+			SetVar("SHARD_TWO", "Shards.2")
+
+		cfg := &testConfig{}
+		r, err := GatherWithReport(opts, cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		wantLimits := map[string]int{"orders": 10, "carts": 3, "users": 5, "default": 100}
+		if !reflect.DeepEqual(cfg.Limits, wantLimits) {
+			t.Errorf("Gather() Limits = %v, want %v", cfg.Limits, wantLimits)
+		}
+
+		wantServices := map[string]testService{
+			"orders": {Host: "orders.internal", Port: 8443},
+			"users":  {Port: 8080},
+		}
+		if !reflect.DeepEqual(cfg.Services, wantServices) {
+			t.Errorf("Gather() Services = %v, want %v", cfg.Services, wantServices)
+		}
+
+		if want := map[string]string{"team": "core", "tier": "1"}; !reflect.DeepEqual(cfg.Labels, want) {
+			t.Errorf("Gather() Labels = %v, want %v", cfg.Labels, want)
+		}
+
+		if want := map[int]string{2: "replica"}; !reflect.DeepEqual(cfg.Shards, want) {
+			t.Errorf("Gather() Shards = %v, want %v", cfg.Shards, want)
+		}
+
+		if fr, _ := r.Field("Limits.users"); fr.Source != SourceVars || fr.Origin != "LIMITS_USERS" || len(fr.Overridden) != 1 || fr.Overridden[0].Origin != "--limits.users" {
+			t.Errorf("Report.Field(Limits.users) = %+v", fr)
+		}
+	})
+
+	t.Run("should error for invalid map keys", func(t *testing.T) {
+		opts := Options().
+			SetEnv(map[string]string{"SHARD": "replica"}).
+			SetVar("SHARD", "Shards.two")
+
+		if err := Gather(opts, &testConfig{}); !errors.Is(err, ErrFieldSet) || !strings.Contains(err.Error(), "invalid map key") {
+			t.Errorf("Gather() expected invalid map key error, got %v", err)
+		}
+	})
+
+	t.Run("should gather into a map target", func(t *testing.T) {
+		servicesPath := filepath.Join(dir, "services.yaml")
+		if err := os.WriteFile(servicesPath, []byte("orders:\n  host: orders.internal\n  port: 80\n"), 0o600); err != nil {
+			t.Fatalf("unable to write base file: %v", err)
+		}
+
+		opts := Options().
+			SetBasePath(servicesPath).
+			SetArgs([]string{"--users-host", "users.internal"}).
+			SetArg("--users-host", "users.Host").
+			SetEnv(map[string]string{"ORDERS_PORT": "8443"}).
+			SetVar("ORDERS_PORT", "orders.Port")
+
+		services, err := Load[map[string]testService](opts)
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+
+		want := map[string]testService{
+			"orders": {Host: "orders.internal", Port: 8443},
+			"users":  {Host: "users.internal"},
+		}
+		if !reflect.DeepEqual(services, want) {
+			t.Errorf("Load() = %v, want %v", services, want)
+		}
+	})
+}
+
+type testBackend struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	CertFile string `yaml:"certFile"`
+}
+
+func TestGather_SliceIndexes(t *testing.T) {
+	type testConfig struct {
+		Backends []testBackend `yaml:"backends" env:"BACKENDS" arg:"--backends"`
+		Ports    [2]int        `env:"PORTS"`
+		Tags     []string      `env:"TAGS"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("backends:\n  - host: a.internal\n    port: 80\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	t.Run("should set and grow slice entries by index", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetDefaultsMap(map[string]interface{}{
+				"Backends.0.CertFile": "a.pem",
+			}).
+			SetArgs([]string{"--backends[1].host=b.internal", "--backends.1.port", "8081"}).
+			SetEnv(map[string]string{
+				"BACKENDS_0_PORT":      "8080",
+				"BACKENDS_2_HOST":      "c.internal",
+				"BACKENDS_2_CERT_FILE": "c.pem",
+				"PORTS_1":              "443",
+				"TAGS":                 "a, b",
+				"TAGS_3":               "d",
+			})
+
+		cfg := &testConfig{}
+		r, err := GatherWithReport(opts, cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		want := []testBackend{
+			{Host: "a.internal", Port: 8080, CertFile: "a.pem"},
+			{Host: "b.internal", Port: 8081},
+			{Host: "c.internal", CertFile: "c.pem"},
+		}
+		if !reflect.DeepEqual(cfg.Backends, want) {
+			t.Errorf("Gather() Backends = %+v, want %+v", cfg.Backends, want)
+		}
+
+		if want := [2]int{0, 443}; cfg.Ports != want {
+			t.Errorf("Gather() Ports = %v, want %v", cfg.Ports, want)
+		}
+
+		if want := []string{"a", "b", "", "d"}; !reflect.DeepEqual(cfg.Tags, want) {
+			t.Errorf("Gather() Tags = %q, want %q", cfg.Tags, want)
+		}
+
+		if fr, _ := r.Field("Backends.1.Host"); fr.Source != SourceArgs || fr.Origin != "--backends[1].host" {
+			t.Errorf("Report.Field(Backends.1.Host) = %+v", fr)
+		}
+	})
+
+	t.Run("should decode JSON literals into the element type", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetArgs([]string{"--backends.1", `{"host":"b.internal","port":81}`}).
+			SetEnv(map[string]string{"BACKENDS": `[{"host":"x.internal"},{"host":"y.internal","certFile":"y.pem"}]`})
+
+		cfg, err := Load[testConfig](opts)
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+
+		want := []testBackend{
+			{Host: "x.internal"},
+			{Host: "y.internal", CertFile: "y.pem"},
+		}
+		if !reflect.DeepEqual(cfg.Backends, want) {
+			t.Errorf("Load() Backends = %+v, want %+v", cfg.Backends, want)
+		}
+	})
+
+	t.Run("should error for indexes beyond the length of an array", func(t *testing.T) {
+		opts := Options().
+			SetEnv(map[string]string{"PORT": "443"}).
+			SetVar("PORT", "Ports.2")
+
+		if err := Gather(opts, &testConfig{}); !errors.Is(err, ErrFieldDoesNotExist) {
+			t.Errorf("Gather() expected field does not exist error, got %v", err)
+		}
+	})
+}
+
+func Test_splitList(t *testing.T) {
+	tests := []struct {
+		name    string
+		sVal    string
+		sep     string
+		want    []string
+		wantErr bool
+	}{
+		{"should trim space around each element", "a,  b ,c", ",", []string{"a", "b", "c"}, false},
+		{"should keep empty elements", "a,,b", ",", []string{"a", "", "b"}, false},
+		{"should split on a custom separator", "a,b; c", ";", []string{"a,b", "c"}, false},
+		{"should split on a multi-character separator", "a || b", "||", []string{"a", "b"}, false},
+		{"should keep separators within quotes", `"x=1,y=2", z`, ",", []string{"x=1,y=2", "z"}, false},
+		{"should unescape doubled quotes", `"say ""hi""",b`, ",", []string{`say "hi"`, "b"}, false},
+		{"should error on a missing closing quote", `"a,b`, ",", nil, true},
+		{"should error on text after a quoted element", `"a"b,c`, ",", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitList(tt.sVal, tt.sep)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("splitList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGather_ListValues(t *testing.T) {
+	type testConfig struct {
+		DSNs   []string          `env:"DSNS" sep:";"`
+		Hosts  []string          `yaml:"hosts" env:"HOSTS" arg:"--host"`
+		Labels map[string]string `env:"LABELS" sep:"|"`
+		Ports  []int             `yaml:"ports" arg:"--port" default:"80"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("hosts:\n  - a.internal\nports:\n  - 8080\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	env := map[string]string{
+		"DSNS":   "host=a port=1,2; host=b",
+		"LABELS": "team=core | tier=1",
+	}
+
+	t.Run("should split on sep tags and accumulate repeated arguments", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetArgs([]string{"--host", "b.internal", "--port=1", "--host", `"c,d.internal", e.internal`}).
+			SetEnv(env)
+
+		cfg, err := Load[testConfig](opts)
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+
+		if want := []string{"host=a port=1,2", "host=b"}; !reflect.DeepEqual(cfg.DSNs, want) {
+			t.Errorf("Load() DSNs = %q, want %q", cfg.DSNs, want)
+		}
+
+		if want := []string{"b.internal", "c,d.internal", "e.internal"}; !reflect.DeepEqual(cfg.Hosts, want) {
+			t.Errorf("Load() Hosts = %q, want %q", cfg.Hosts, want)
+		}
+
+		if want := map[string]string{"team": "core", "tier": "1"}; !reflect.DeepEqual(cfg.Labels, want) {
+			t.Errorf("Load() Labels = %v, want %v", cfg.Labels, want)
+		}
+
+		if want := []int{1}; !reflect.DeepEqual(cfg.Ports, want) {
+			t.Errorf("Load() Ports = %v, want %v", cfg.Ports, want)
+		}
+	})
+
+	t.Run("should use the list separator option", func(t *testing.T) {
+		opts := Options().
+			SetListSeparator(" ").
+			SetEnv(map[string]string{"HOSTS": "a.internal b.internal"})
+
+		cfg, err := Load[testConfig](opts)
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+
+		if want := []string{"a.internal", "b.internal"}; !reflect.DeepEqual(cfg.Hosts, want) {
+			t.Errorf("Load() Hosts = %q, want %q", cfg.Hosts, want)
+		}
+	})
+
+	t.Run("should append to slices from files", func(t *testing.T) {
+		opts := Options().
+			SetAppendSlices(true).
+			SetBasePath(basePath).
+			SetArgs([]string{"--host", "b.internal", "--port", "8081"}).
+			SetEnv(map[string]string{"HOSTS": "c.internal"})
+
+		cfg, err := Load[testConfig](opts)
+		if err != nil {
+			t.Fatalf("Load() unexpected error = %v", err)
+		}
+
+		if want := []string{"a.internal", "b.internal", "c.internal"}; !reflect.DeepEqual(cfg.Hosts, want) {
+			t.Errorf("Load() Hosts = %q, want %q", cfg.Hosts, want)
+		}
+
+		if want := []int{8080, 8081}; !reflect.DeepEqual(cfg.Ports, want) {
+			t.Errorf("Load() Ports = %v, want %v", cfg.Ports, want)
+		}
+	})
+}
+
+func TestGather_ZeroValues(t *testing.T) {
+	type testConfig struct {
+		Name    string   `yaml:"name" env:"NAME"`
+		Retries int      `yaml:"retries" env:"RETRIES" arg:"--retries"`
+		Tags    []string `yaml:"tags" env:"TAGS"`
+		Verbose bool     `yaml:"verbose" arg:"--verbose"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("name: base\nretries: 3\ntags: [a]\nverbose: true\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	t.Run("should apply explicit zero values", func(t *testing.T) {
+		opts := Options().
+			SetBasePath(basePath).
+			SetArgs([]string{"--verbose=false"}).
+			SetEnv(map[string]string{"RETRIES": "0", "NAME": ""})
+
+		cfg := &testConfig{}
+		r, err := GatherWithReport(opts, cfg)
+		if err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		want := &testConfig{Name: "base", Tags: []string{"a"}}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Gather() = %+v, want %+v", cfg, want)
+		}
+
+		if fr, _ := r.Field("Retries"); fr.Source != SourceVars {
+			t.Errorf("Report.Field(Retries) source = %v, want %v", fr.Source, SourceVars)
+		}
+
+		if fr, _ := r.Field("Name"); fr.Source != SourceBaseFile {
+			t.Errorf("Report.Field(Name) source = %v, want %v", fr.Source, SourceBaseFile)
+		}
+	})
+
+	t.Run("should clear fields with empty values", func(t *testing.T) {
+		opts := Options().
+			SetEmptyValues(EmptyClear).
+			SetBasePath(basePath).
+			SetArgs([]string{"--retries="}).
+			SetEnv(map[string]string{"NAME": "", "TAGS": ""})
+
+		cfg := &testConfig{}
+		if err := Gather(opts, cfg); err != nil {
+			t.Fatalf("Gather() unexpected error = %v", err)
+		}
+
+		want := &testConfig{Verbose: true}
+		if !reflect.DeepEqual(cfg, want) {
+			t.Errorf("Gather() = %+v, want %+v", cfg, want)
+		}
+	})
+}
+
+func TestGather_BoolSwitches(t *testing.T) {
+	type testConfig struct {
+		Color   bool   `yaml:"color" arg:"--color"`
+		Debug   *bool  `arg:"-d"`
+		Name    string `arg:"--name"`
+		Quiet   bool   `arg:"-q"`
+		Verbose bool   `arg:"-v"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("color: true\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	debug := true
+
+	tests := []struct {
+		name string
+		args []string
+		want testConfig
+	}{
+		{
+			"should set switches without values",
+			[]string{"-v", "--name", "svc", "-d"},
+			testConfig{Color: true, Debug: &debug, Name: "svc", Verbose: true},
+		},
+		{
+			"should negate switches",
+			[]string{"--no-color", "-v=false"},
+			testConfig{},
+		},
+		{
+			"should use the last of repeated switches",
+			[]string{"--no-color", "--color=false", "--color"},
+			testConfig{Color: true},
+		},
+		{
+			"should not take the next argument as the value of a switch",
+			[]string{"--no-color", "-v", "false", "--color", "0"},
+			testConfig{Color: true, Verbose: true},
+		},
+		{
+			"should split combined short switches",
+			[]string{"-vqd"},
+			testConfig{Color: true, Debug: &debug, Quiet: true, Verbose: true},
+		},
+		{
+			"should not split combined switches with unknown letters",
+			[]string{"-vx"},
+			testConfig{Color: true},
+		},
+		{
+			"should not read arguments after the terminator",
+			[]string{"-v", "--", "-q", "--name", "positional"},
+			testConfig{Color: true, Verbose: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options().
+				SetBasePath(basePath).
+				SetArgs(tt.args)
+
+			cfg := &testConfig{}
+			if err := Gather(opts, cfg); err != nil {
+				t.Fatalf("Gather() unexpected error = %v", err)
+			}
+
+			if !reflect.DeepEqual(*cfg, tt.want) {
+				t.Errorf("Gather() = %+v, want %+v", *cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("should report the negated switch as the origin", func(t *testing.T) {
+		r, err := GatherWithReport(Options().SetBasePath(basePath).SetArgs([]string{"--no-color"}), &testConfig{})
+		if err != nil {
+			t.Fatalf("GatherWithReport() unexpected error = %v", err)
+		}
+
+		if fr, _ := r.Field("Color"); fr.Source != SourceArgs || fr.Origin != "--no-color" {
+			t.Errorf("Report.Field(Color) = %+v", fr)
+		}
+	})
+}
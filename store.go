@@ -0,0 +1,79 @@
+package settings
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// Store holds the most recently gathered settings for a struct type as an
+// immutable snapshot that can be read concurrently without locking while
+// settings are reloaded in the background
+type Store[T any] struct {
+	cur  atomic.Pointer[T]
+	mu   sync.Mutex
+	opts ReadOptions
+}
+
+// NewStore gathers settings into a new T and returns a Store holding the result
+func NewStore[T any](opts ReadOptions) (*Store[T], error) {
+	st := &Store[T]{opts: opts}
+	if err := st.Reload(); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// Load returns the current snapshot; the returned value is shared by every
+// caller and must not be modified
+func (st *Store[T]) Load() *T {
+	return st.cur.Load()
+}
+
+// Reload gathers settings into a new T and, only when gathering succeeds,
+// replaces the current snapshot (otherwise the previous snapshot is kept)
+func (st *Store[T]) Reload() error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	next, err := Load[T](st.opts)
+	if err != nil {
+		return err
+	}
+
+	st.cur.Store(&next)
+
+	return nil
+}
+
+// ReloadOnSignal reloads settings each time the process receives one of the
+// specified signals (SIGHUP when none are specified) until ctx is done. Errors
+// while reloading are provided to the func set via
+// ReadOptions.SetWatchErrorHandler and the previous snapshot is kept.
+func (st *Store[T]) ReloadOnSignal(ctx context.Context, sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig...)
+
+	go func() {
+		defer signal.Stop(c)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c:
+				if err := st.Reload(); err != nil && st.opts.WatchErrorHandler != nil {
+					st.opts.WatchErrorHandler(err)
+				}
+			}
+		}
+	}()
+}
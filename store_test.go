@@ -0,0 +1,121 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	type testConfig struct {
+		Name string `yaml:"name"`
+	}
+
+	t.Parallel()
+
+	basePath := filepath.Join(t.TempDir(), "base.yaml")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(basePath, []byte(content), 0o600); err != nil {
+			t.Fatalf("unable to write base file: %v", err)
+		}
+	}
+
+	write("name: first\n")
+
+	st, err := NewStore[testConfig](Options().SetBasePath(basePath))
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error = %v", err)
+	}
+
+	first := st.Load()
+	if first.Name != "first" {
+		t.Fatalf("Store.Load() Name = %s, want first", first.Name)
+	}
+
+	write("name: second\n")
+	if err := st.Reload(); err != nil {
+		t.Fatalf("Store.Reload() unexpected error = %v", err)
+	}
+
+	if got := st.Load(); got.Name != "second" || first.Name != "first" {
+		t.Errorf("Store.Reload() Load() = %+v, previous snapshot = %+v", got, first)
+	}
+
+	write("name: [broken\n")
+	if err := st.Reload(); !errors.Is(err, ErrFileParse) {
+		t.Errorf("Store.Reload() error = %v, want ErrFileParse", err)
+	}
+
+	if got := st.Load(); got.Name != "second" {
+		t.Errorf("Store.Reload() should keep the previous snapshot, got %+v", got)
+	}
+
+	// snapshots can be read while reloading
+	write("name: third\n")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = st.Reload()
+		}()
+		go func() {
+			defer wg.Done()
+			if st.Load() == nil {
+				t.Errorf("Store.Load() returned nil")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, err := NewStore[testConfig](Options().SetBasePath("./does/not/exist.yml")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("NewStore() error = %v, want ErrFileNotFound", err)
+	}
+}
+
+func TestStore_ReloadOnSignal(t *testing.T) {
+	type testConfig struct {
+		Name string `yaml:"name"`
+	}
+
+	basePath := filepath.Join(t.TempDir(), "base.yaml")
+	if err := os.WriteFile(basePath, []byte("name: first\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	st, err := NewStore[testConfig](Options().SetBasePath(basePath))
+	if err != nil {
+		t.Fatalf("NewStore() unexpected error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	st.ReloadOnSignal(ctx)
+
+	if err := os.WriteFile(basePath, []byte("name: second\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("unable to find process: %v", err)
+	}
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("unable to send SIGHUP: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for st.Load().Name != "second" {
+		if time.Now().After(deadline) {
+			t.Fatalf("Store.ReloadOnSignal() did not reload, Name = %s", st.Load().Name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,197 @@
+package settings
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Usage returns help text for out (a struct, or a pointer to one) listing the
+// command line arguments and environment variables mapped to each field (via
+// tags or opts) along with the field type, default value (from the default tag
+// or DefaultsMap) and desc tag, grouped by the nested struct of each field
+func Usage(out any, opts ReadOptions) string {
+	s := &settings{
+		fieldTypeMap: map[string]reflect.Type{},
+		out:          out,
+	}
+
+	if err := s.determineFieldTypes(); err != nil {
+		return ""
+	}
+
+	s.plan.applyTags(&opts)
+
+	return s.usage(opts)
+}
+
+// helpRequested returns true when -h or --help is provided (and isn't mapped
+// to a field)
+func (s *settings) helpRequested(argsMap map[string]string) bool {
+	for _, oa := range s.osArgs() {
+		if oa != "-h" && oa != "--help" {
+			continue
+		}
+
+		if _, ok := argsMap[oa]; !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *settings) usage(opts ReadOptions) string {
+	args := s.mappedNames(opts.ArgsMap)
+	vars := s.mappedNames(opts.VarsMap)
+
+	defaults := s.defaultValues(opts)
+
+	// structs with a cmd tag are labelled with the subcommand
+	commands := map[string]string{}
+	for cmd, fieldPath := range s.plan.commands {
+		commands[fieldPath] = cmd
+	}
+
+	// fields are listed in declaration order, followed by any explicit
+	// mappings for other paths (i.e. map entries)
+	fieldPaths := append([]string{}, s.plan.fields...)
+	listed := map[string]bool{}
+	for _, fieldPath := range fieldPaths {
+		listed[fieldPath] = true
+	}
+	for _, mapped := range []map[string][]string{args, vars} {
+		for _, fieldPath := range sortedKeys(mapped) {
+			if !listed[fieldPath] {
+				fieldPaths = append(fieldPaths, fieldPath)
+				listed[fieldPath] = true
+			}
+		}
+	}
+
+	// group the fields by nested struct with top level fields first
+	groups := map[string][]string{}
+	order := []string{""}
+	for _, fieldPath := range fieldPaths {
+		if len(args[fieldPath]) == 0 && len(vars[fieldPath]) == 0 {
+			continue
+		}
+
+		group := ""
+		if i := strings.LastIndex(fieldPath, "."); i >= 0 {
+			group = fieldPath[:i]
+		}
+
+		if _, ok := groups[group]; !ok && group != "" {
+			order = append(order, group)
+		}
+
+		groups[group] = append(groups[group], fieldPath)
+	}
+
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+
+	for _, group := range order {
+		if len(groups[group]) == 0 {
+			continue
+		}
+
+		// groups are separated by a blank line
+		if b.Len() > 0 {
+			fmt.Fprintln(tw)
+		}
+
+		switch cmd := commands[group]; {
+		case group == "":
+			fmt.Fprintln(tw, "Options:")
+		case cmd != "":
+			fmt.Fprintf(tw, "%s (%s command):\n", group, cmd)
+		default:
+			fmt.Fprintf(tw, "%s:\n", group)
+		}
+
+		for _, fieldPath := range groups[group] {
+			fmt.Fprintf(
+				tw,
+				"  %s\t%s\t%s\t%s\n",
+				strings.Join(args[fieldPath], ", "),
+				strings.Join(vars[fieldPath], ", "),
+				s.usageType(fieldPath),
+				s.usageDesc(fieldPath, defaults))
+		}
+
+		tw.Flush()
+	}
+
+	// trim the padding left after fields without a description
+	lines := strings.Split(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// defaultValues returns the default value of each field as a string, from
+// the DefaultsMap or otherwise the default tag
+func (s *settings) defaultValues(opts ReadOptions) map[string]string {
+	defaults := map[string]string{}
+	for fieldPath, def := range s.plan.defaults {
+		defaults[fieldPath] = def
+	}
+	for name, def := range opts.DefaultsMap {
+		defaults[s.resolvePath(name)] = fmt.Sprint(def)
+	}
+
+	return defaults
+}
+
+// mappedNames inverts an ArgsMap or VarsMap into the sorted names (shortest
+// first) mapped to each field path
+func (s *settings) mappedNames(m map[string]string) map[string][]string {
+	names := map[string][]string{}
+	for key, fieldPath := range m {
+		_, name := splitArg(key)
+		fieldPath = s.resolvePath(fieldPath)
+		names[fieldPath] = append(names[fieldPath], name)
+	}
+
+	for _, n := range names {
+		sort.Slice(n, func(i, j int) bool {
+			if len(n[i]) != len(n[j]) {
+				return len(n[i]) < len(n[j])
+			}
+
+			return n[i] < n[j]
+		})
+	}
+
+	return names
+}
+
+func (s *settings) usageType(fieldPath string) string {
+	t, ok := s.fieldType(fieldPath)
+	if !ok {
+		return ""
+	}
+
+	return derefType(t).String()
+}
+
+func (s *settings) usageDesc(fieldPath string, defaults map[string]string) string {
+	desc := s.plan.descriptions[fieldPath]
+
+	def, ok := defaults[fieldPath]
+	if !ok || def == "" {
+		return desc
+	}
+
+	if t, ok := s.fieldType(fieldPath); ok && derefType(t).Kind() == reflect.String {
+		def = fmt.Sprintf("%q", def)
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s (default: %s)", desc, def))
+}
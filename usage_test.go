@@ -0,0 +1,85 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type testUsageConfig struct {
+	Name    string `arg:"--name" env:"NAME" desc:"service name" default:"svc"`
+	Verbose bool   `arg:"-v" desc:"verbose output"`
+	Data    struct {
+		Host     string `arg:"--data-host" env:"DATA_HOST" desc:"database host"`
+		Port     int    `arg:"--data-port" env:"DATA_PORT"`
+		Internal string
+	}
+	Timeout int `env:"TIMEOUT"`
+}
+
+const testUsage = `Options:
+  --name         NAME     string  service name (default: "svc")
+  -v, --verbose           bool    verbose output
+                 TIMEOUT  int
+
+Data:
+  --data-host  DATA_HOST  string  database host
+  --data-port  DATA_PORT  int     (default: 5432)
+`
+
+func TestUsage(t *testing.T) {
+	opts := Options().
+		SetArg("--verbose", "Verbose").
+		SetDefaultsMap(map[string]interface{}{"Data.Port": 5432})
+
+	if got := Usage(&testUsageConfig{}, opts); got != testUsage {
+		t.Errorf("Usage() = \n%s\nwant\n%s", got, testUsage)
+	}
+
+	if got := Usage("not a struct", opts); got != "" {
+		t.Errorf("Usage() = %q, want empty usage for a non-struct", got)
+	}
+}
+
+func TestGather_Help(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantHelp bool
+	}{
+		{"should print usage for -h", []string{"-h"}, true},
+		{"should print usage for --help", []string{"--name", "x", "--help"}, true},
+		{"should ignore help after the terminator", []string{"--", "--help"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			opts := Options().
+				SetArgs(tt.args).
+				SetArg("--verbose", "Verbose").
+				SetDefaultsMap(map[string]interface{}{"Data.Port": 5432}).
+				SetHelpOutput(&out)
+
+			err := Gather(opts, &testUsageConfig{})
+			if got := errors.Is(err, ErrHelp); got != tt.wantHelp {
+				t.Fatalf("Gather() error = %v, want ErrHelp %v", err, tt.wantHelp)
+			}
+
+			if tt.wantHelp && out.String() != testUsage {
+				t.Errorf("Gather() help output = \n%s\nwant\n%s", out.String(), testUsage)
+			}
+
+			if !tt.wantHelp && out.Len() > 0 {
+				t.Errorf("Gather() unexpected help output = %s", out.String())
+			}
+		})
+	}
+
+	t.Run("should not handle help unless enabled", func(t *testing.T) {
+		if err := Gather(Options().SetArgs([]string{"-h"}), &testUsageConfig{}); err != nil {
+			t.Errorf("Gather() unexpected error = %v", err)
+		}
+	})
+}
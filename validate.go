@@ -0,0 +1,250 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validator can be implemented by the out struct, or any struct nested within
+// it, to validate settings once every layer has been applied
+type Validator interface {
+	Validate() error
+}
+
+type rule struct {
+	name string
+	arg  string
+}
+
+// parseRules reads the comma separated rules from a validate tag; a regexp
+// rule must be last as the remainder of the tag (commas included) is the pattern
+func parseRules(tag string) []rule {
+	rules := []rule{}
+
+	for tag != "" {
+		if strings.HasPrefix(tag, "regexp=") {
+			rules = append(rules, rule{"regexp", strings.TrimPrefix(tag, "regexp=")})
+			break
+		}
+
+		part := tag
+		tag = ""
+		if c := strings.Index(part, ","); c >= 0 {
+			part, tag = part[:c], part[c+1:]
+		}
+
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			rules = append(rules, rule{name, arg})
+		}
+	}
+
+	return rules
+}
+
+// validate checks the final value of every field with a validate tag and
+// then calls Validate on each struct (nested structs first) that implements
+// Validator
+func (s *settings) validate() error {
+	var errs SettingsErrors
+
+	for _, fieldPath := range sortedKeys(s.plan.validate) {
+		// fields within nil pointers (and nil pointer fields) are not validated
+		v := s.findOutFieldValue(fieldPath)
+		for v.IsValid() && v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+
+		if !v.IsValid() {
+			continue
+		}
+
+		fr, _ := s.report.Field(fieldPath)
+		for _, r := range parseRules(s.plan.validate[fieldPath]) {
+			// the rules after omitempty are only checked for non zero values
+			if r.name == "omitempty" {
+				if v.IsZero() {
+					break
+				}
+
+				continue
+			}
+
+			if err := checkRule(v, r); err != nil {
+				errs = errs.append(withSource(
+					SettingsFieldInvalid(fieldPath, r.name, err),
+					fr.Source,
+					fr.Origin,
+					fmt.Sprint(v.Interface())))
+				break
+			}
+		}
+	}
+
+	s.validateStructs(reflect.ValueOf(s.out), "", &errs)
+
+	return errs.err()
+}
+
+func (s *settings) validateStructs(v reflect.Value, prefix string, errs *SettingsErrors) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	if isLeaf(v.Type()) {
+		return
+	}
+
+	// validate nested structs first
+	for i := 0; i < v.NumField(); i++ {
+		fld := v.Type().Field(i)
+		if !fld.IsExported() {
+			continue
+		}
+
+		fldNm := fld.Name
+		if prefix != "" {
+			fldNm = fmt.Sprintf("%s.%s", prefix, fldNm)
+		}
+
+		s.validateStructs(v.Field(i), fldNm, errs)
+	}
+
+	var vr Validator
+	if v.CanAddr() {
+		vr, _ = v.Addr().Interface().(Validator)
+	} else if v.CanInterface() {
+		vr, _ = v.Interface().(Validator)
+	}
+
+	if vr == nil {
+		return
+	}
+
+	if err := vr.Validate(); err != nil {
+		*errs = errs.append(SettingsFieldInvalid(prefix, "Validate", err))
+	}
+}
+
+// checkRule returns an error describing why the value does not satisfy the rule
+func checkRule(v reflect.Value, r rule) error {
+	switch r.name {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(r.arg, 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s rule %q", r.name, r.arg)
+		}
+
+		n, isLen, ok := measure(v)
+		if !ok {
+			return fmt.Errorf("%s rule is not supported for %v", r.name, v.Kind())
+		}
+
+		desc := "value"
+		if isLen {
+			desc = "length"
+		}
+
+		if r.name == "min" && n < limit {
+			return fmt.Errorf("%s must be at least %s", desc, r.arg)
+		}
+
+		if r.name == "max" && n > limit {
+			return fmt.Errorf("%s must be at most %s", desc, r.arg)
+		}
+	case "len":
+		want, err := strconv.Atoi(r.arg)
+		if err != nil {
+			return fmt.Errorf("invalid len rule %q", r.arg)
+		}
+
+		if !hasLen(v) {
+			return fmt.Errorf("len rule is not supported for %v", v.Kind())
+		}
+
+		if v.Len() != want {
+			return fmt.Errorf("length must be %d", want)
+		}
+	case "nonempty":
+		if (hasLen(v) && v.Len() == 0) || (!hasLen(v) && v.IsZero()) {
+			return errors.New("value must not be empty")
+		}
+	case "oneof":
+		val := fmt.Sprint(v.Interface())
+		for _, opt := range strings.Fields(r.arg) {
+			if val == opt {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("value must be one of [%s]", r.arg)
+	case "regexp":
+		re, err := regexp.Compile(r.arg)
+		if err != nil {
+			return fmt.Errorf("invalid regexp rule %q: %w", r.arg, err)
+		}
+
+		if !re.MatchString(fmt.Sprint(v.Interface())) {
+			return fmt.Errorf("value must match %s", r.arg)
+		}
+	case "url":
+		u, err := url.Parse(fmt.Sprint(v.Interface()))
+		if err != nil {
+			return err
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return errors.New("value must be an absolute URL")
+		}
+	case "hostport":
+		_, port, err := net.SplitHostPort(fmt.Sprint(v.Interface()))
+		if err != nil {
+			return err
+		}
+
+		if p, err := strconv.ParseUint(port, 10, 16); err != nil || p == 0 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	default:
+		return fmt.Errorf("unknown validate rule %q", r.name)
+	}
+
+	return nil
+}
+
+// measure returns the number used by min and max rules (the length of
+// strings, slices and maps, otherwise the numeric value)
+func measure(v reflect.Value) (float64, bool, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	}
+
+	if hasLen(v) {
+		return float64(v.Len()), true, true
+	}
+
+	return 0, false, false
+}
+
+func hasLen(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return true
+	}
+
+	return false
+}
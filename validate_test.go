@@ -0,0 +1,228 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseRules(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want []rule
+	}{
+		{
+			"should parse rules with and without arguments",
+			"min=1,max=65535,nonempty",
+			[]rule{{"min", "1"}, {"max", "65535"}, {"nonempty", ""}},
+		},
+		{
+			"should keep spaces within oneof",
+			"oneof=debug info warn error",
+			[]rule{{"oneof", "debug info warn error"}},
+		},
+		{
+			"should read the remainder of the tag as a regexp",
+			"nonempty,regexp=^[a-z]{1,3}$",
+			[]rule{{"nonempty", ""}, {"regexp", "^[a-z]{1,3}$"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRules(tt.tag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		rule    rule
+		wantErr string
+	}{
+		{"min passes", 1, rule{"min", "1"}, ""},
+		{"min fails", 0, rule{"min", "1"}, "value must be at least 1"},
+		{"max fails for uint", uint16(8080), rule{"max", "1024"}, "value must be at most 1024"},
+		{"min checks string length", "ab", rule{"min", "3"}, "length must be at least 3"},
+		{"max checks slice length", []string{"a", "b"}, rule{"max", "1"}, "length must be at most 1"},
+		{"min with invalid argument", 1, rule{"min", "one"}, "invalid min rule"},
+		{"min unsupported for bool", true, rule{"min", "1"}, "not supported"},
+		{"len passes", []int{1, 2}, rule{"len", "2"}, ""},
+		{"len fails", []int{1}, rule{"len", "2"}, "length must be 2"},
+		{"nonempty fails for string", "", rule{"nonempty", ""}, "must not be empty"},
+		{"nonempty fails for slice", []string{}, rule{"nonempty", ""}, "must not be empty"},
+		{"nonempty passes for int", 5, rule{"nonempty", ""}, ""},
+		{"oneof passes", "warn", rule{"oneof", "debug info warn error"}, ""},
+		{"oneof fails", "trace", rule{"oneof", "debug info warn error"}, "must be one of"},
+		{"regexp passes", "abc", rule{"regexp", "^[a-z]+$"}, ""},
+		{"regexp fails", "ABC", rule{"regexp", "^[a-z]+$"}, "must match"},
+		{"url passes", "https://example.com/path", rule{"url", ""}, ""},
+		{"url fails for relative", "/path", rule{"url", ""}, "absolute URL"},
+		{"hostport passes", "localhost:8080", rule{"hostport", ""}, ""},
+		{"hostport fails without port", "localhost", rule{"hostport", ""}, "missing port"},
+		{"hostport fails for invalid port", "localhost:99999", rule{"hostport", ""}, "invalid port"},
+		{"unknown rule", 1, rule{"positive", ""}, "unknown validate rule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRule(reflect.ValueOf(tt.value), tt.rule)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkRule() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkRule() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type validatedData struct {
+	Host string `yaml:"host" validate:"nonempty"`
+	Port int    `yaml:"port" arg:"--data-port" env:"DATA_PORT" validate:"min=1,max=65535"`
+}
+
+func (d validatedData) Validate() error {
+	if d.Host == "localhost" && d.Port == 80 {
+		return errors.New("port 80 is reserved on localhost")
+	}
+
+	return nil
+}
+
+func TestGather_Validate(t *testing.T) {
+	type testConfig struct {
+		Data  validatedData `yaml:"data"`
+		Level string        `yaml:"level" validate:"oneof=debug info warn error"`
+	}
+
+	t.Parallel()
+
+	basePath := filepath.Join(t.TempDir(), "base.yaml")
+	if err := os.WriteFile(basePath, []byte("data:\n  host: localhost\n  port: 0\nlevel: info\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		wantSource Source
+		wantOrigin string
+		wantErr    string
+	}{
+		{
+			name:       "should report the base file as the source",
+			wantSource: SourceBaseFile,
+			wantOrigin: basePath,
+			wantErr:    "Data.Port (min): value must be at least 1",
+		},
+		{
+			name:       "should report the environment variable as the source",
+			env:        map[string]string{"DATA_PORT": "70000"},
+			wantSource: SourceVars,
+			wantOrigin: "DATA_PORT",
+			wantErr:    "Data.Port (max): value must be at most 65535",
+		},
+		{
+			name:       "should report the command line argument as the source",
+			args:       []string{"--data-port", "-1"},
+			wantSource: SourceArgs,
+			wantOrigin: "--data-port",
+			wantErr:    "Data.Port (min): value must be at least 1",
+		},
+		{
+			name:    "should call Validate on nested structs",
+			args:    []string{"--data-port", "80"},
+			wantErr: "Data (Validate): port 80 is reserved on localhost",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := Options().
+				SetArgs(tt.args).
+				SetEnv(tt.env).
+				SetBasePath(basePath)
+
+			err := Gather(opts, &testConfig{})
+			if !errors.Is(err, ErrFieldInvalid) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Gather() error = %v, want %q", err, tt.wantErr)
+			}
+
+			var se SettingsError
+			if !errors.As(err, &se) || se.Source != tt.wantSource || se.Origin != tt.wantOrigin {
+				t.Errorf("Gather() error source = %s %s, want %s %s", se.Source, se.Origin, tt.wantSource, tt.wantOrigin)
+			}
+		})
+	}
+
+	cfg := &testConfig{}
+	if err := Gather(Options().SetBasePath(basePath).SetArgs([]string{"--data-port", "8080"}), cfg); err != nil {
+		t.Fatalf("Gather() unexpected error = %v", err)
+	}
+}
+
+func TestGather_ValidateUnset(t *testing.T) {
+	type testConfig struct {
+		Name  string `arg:"--name" validate:"nonempty"`
+		Port  int    `arg:"--port" validate:"min=1,max=65535"`
+		Proxy string `arg:"--proxy" validate:"omitempty,url"`
+	}
+
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "should check rules for fields that were not set",
+			args:    []string{"svc", "--name", "svc"},
+			wantErr: "Port (min)",
+		},
+		{
+			name:    "should check nonempty for fields that were not set",
+			args:    []string{"svc", "--port", "8080"},
+			wantErr: "Name (nonempty)",
+		},
+		{
+			name: "should skip the rules after omitempty for empty values",
+			args: []string{"svc", "--name", "svc", "--port", "8080"},
+		},
+		{
+			name:    "should check the rules after omitempty for other values",
+			args:    []string{"svc", "--name", "svc", "--port", "8080", "--proxy", "proxy.internal"},
+			wantErr: "Proxy (url)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Gather(Options().SetArgs(tt.args).SetEnv(map[string]string{}), &testConfig{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Gather() unexpected error = %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrFieldInvalid) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Gather() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
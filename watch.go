@@ -0,0 +1,200 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// defaultWatchInterval is used by Watch when ReadOptions.WatchInterval is not set
+const defaultWatchInterval = time.Second
+
+type watcher struct {
+	onChange func(old, new any, changed []string)
+	onError  func(error)
+	opts     ReadOptions
+	out      reflect.Value
+	s        *settings
+	stamps   map[string]string
+}
+
+// Watch gathers settings into out exactly as Gather does and then, until ctx
+// is done, polls every settings file that was considered while gathering (the
+// base file, command line and environment override files and dotenv files) for
+// changes. When a file changes, every layer is gathered again into a new value
+// and, only when that succeeds, out is replaced and onChange is called with
+// copies of the old and new values and the dotted path of each changed field.
+// Errors while gathering after a change are provided to the func set via
+// ReadOptions.SetWatchErrorHandler and out keeps its previous value.
+//
+// out is replaced from a separate goroutine, so any other goroutines reading
+// from it should synchronize with onChange (i.e. by keeping the new value).
+func Watch(ctx context.Context, opts ReadOptions, out any, onChange func(old, new any, changed []string)) error {
+	if out == nil {
+		return SettingsOutCannotBeNil()
+	}
+
+	// out is replaced in place, so it must be a pointer
+	ov := reflect.ValueOf(out)
+	if ov.Kind() != reflect.Ptr {
+		return SettingsTypeDiscoveryError(ov.Kind())
+	}
+
+	if ov.IsNil() {
+		return SettingsOutCannotBeNil()
+	}
+
+	s, err := gather(opts, out)
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{
+		onChange: onChange,
+		onError:  opts.WatchErrorHandler,
+		opts:     opts,
+		out:      ov,
+		s:        s,
+		stamps:   s.fileStamps(),
+	}
+
+	interval := opts.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	go w.run(ctx, interval)
+
+	return nil
+}
+
+func (w *watcher) run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if stamps := w.s.fileStamps(); !maps.Equal(stamps, w.stamps) {
+				w.reload()
+			}
+		}
+	}
+}
+
+// reload gathers every layer into a new value and swaps it into out when
+// gathering succeeds and at least one field has changed
+func (w *watcher) reload() {
+	next := reflect.New(w.out.Elem().Type())
+	s, err := gather(w.opts, next.Interface())
+
+	// watch the files considered during this attempt (i.e. an environment
+	// override file may now exist) so that a broken file is not retried
+	// until it changes again
+	w.s = s
+	w.stamps = s.fileStamps()
+
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+
+		return
+	}
+
+	changed := changedFields(watchedPaths(s, w.out, next), w.out.Interface(), next.Interface())
+	if len(changed) == 0 {
+		return
+	}
+
+	prev := reflect.New(w.out.Elem().Type())
+	prev.Elem().Set(w.out.Elem())
+	w.out.Elem().Set(next.Elem())
+
+	if w.onChange != nil {
+		w.onChange(prev.Interface(), next.Interface(), changed)
+	}
+}
+
+// fileStamps fingerprints every watched file in each filesystem using the
+// modification time and size (or a marker when the file does not exist)
+func (s *settings) fileStamps() map[string]string {
+	stamps := map[string]string{}
+
+	for _, name := range s.watched {
+		var b strings.Builder
+		for _, fsys := range s.filesystems() {
+			fi, err := fs.Stat(fsys, fsPath(fsys, name))
+			if err != nil {
+				b.WriteString("-;")
+				continue
+			}
+
+			fmt.Fprintf(&b, "%d:%d;", fi.ModTime().UnixNano(), fi.Size())
+		}
+
+		stamps[name] = b.String()
+	}
+
+	return stamps
+}
+
+// watchedPaths returns the field paths to compare between old and new; map
+// targets have no fields of their own, so each entry in either is compared
+func watchedPaths(s *settings, old reflect.Value, new reflect.Value) []string {
+	if len(s.fieldTypeMap) > 0 {
+		return sortedKeys(s.fieldTypeMap)
+	}
+
+	entries := map[string]bool{}
+	for _, v := range []reflect.Value{old, new} {
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Map {
+			continue
+		}
+
+		for _, k := range v.MapKeys() {
+			entries[fmt.Sprint(k.Interface())] = true
+		}
+	}
+
+	return sortedKeys(entries)
+}
+
+// changedFields returns each of the field paths with a different value in old and new
+func changedFields(fieldPaths []string, old any, new any) []string {
+	changed := []string{}
+	from := &settings{out: old}
+	to := &settings{out: new}
+
+	for _, p := range fieldPaths {
+		ov := from.findOutFieldValue(p)
+		nv := to.findOutFieldValue(p)
+
+		// fields within a nil pointer are invalid, so a pointer that is
+		// allocated (or set to nil) changes each of the fields within it
+		if ov.IsValid() != nv.IsValid() {
+			changed = append(changed, p)
+			continue
+		}
+
+		if !ov.IsValid() || !ov.CanInterface() || !nv.CanInterface() {
+			continue
+		}
+
+		if !reflect.DeepEqual(ov.Interface(), nv.Interface()) {
+			changed = append(changed, p)
+		}
+	}
+
+	return changed
+}
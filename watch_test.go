@@ -0,0 +1,256 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	type testConfig struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+		Host    string `yaml:"host"`
+	}
+
+	type change struct {
+		old     *testConfig
+		new     *testConfig
+		changed []string
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	envPath := filepath.Join(dir, "staging.yaml")
+	write := func(path string, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("unable to write %s: %v", path, err)
+		}
+	}
+
+	write(basePath, "name: base\nversion: \"1.0\"\nhost: localhost\n")
+
+	changes := make(chan change, 1)
+	errs := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := Options().
+		SetEnv(map[string]string{"GO_ENV": "staging"}).
+		SetBasePath(basePath).
+		SetEnvOverride("GO_ENV").
+		SetEnvSearchPaths(dir).
+		SetWatchInterval(10 * time.Millisecond).
+		SetWatchErrorHandler(func(err error) {
+			errs <- err
+		})
+
+	cfg := &testConfig{}
+	err := Watch(ctx, opts, cfg, func(old, new any, changed []string) {
+		changes <- change{old.(*testConfig), new.(*testConfig), changed}
+	})
+	if err != nil {
+		t.Fatalf("Watch() unexpected error = %v", err)
+	}
+
+	if cfg.Name != "base" {
+		t.Fatalf("Watch() Name = %s, want base", cfg.Name)
+	}
+
+	wait := func() change {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case err := <-errs:
+			t.Fatalf("Watch() unexpected error = %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Watch() timed out waiting for a change")
+		}
+
+		return change{}
+	}
+
+	t.Run("should reload when the base file changes", func(t *testing.T) {
+		write(basePath, "name: base\nversion: \"1.1.0\"\nhost: localhost\n")
+
+		c := wait()
+		if !reflect.DeepEqual(c.changed, []string{"Version"}) {
+			t.Errorf("Watch() changed = %v, want [Version]", c.changed)
+		}
+		if c.old.Version != "1.0" || c.new.Version != "1.1.0" || cfg.Version != "1.1.0" {
+			t.Errorf("Watch() old = %+v, new = %+v, out = %+v", c.old, c.new, cfg)
+		}
+	})
+
+	t.Run("should reload when an environment override file appears", func(t *testing.T) {
+		write(envPath, "host: staging.internal\nname: staging\n")
+
+		c := wait()
+		if !reflect.DeepEqual(c.changed, []string{"Host", "Name"}) {
+			t.Errorf("Watch() changed = %v, want [Host Name]", c.changed)
+		}
+		if cfg.Host != "staging.internal" {
+			t.Errorf("Watch() Host = %s, want staging.internal", cfg.Host)
+		}
+	})
+
+	t.Run("should keep the previous value when gathering fails", func(t *testing.T) {
+		write(envPath, "host: [broken\n")
+
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrFileParse) {
+				t.Errorf("Watch() error = %v, want ErrFileParse", err)
+			}
+		case c := <-changes:
+			t.Fatalf("Watch() unexpected change = %v", c.changed)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Watch() timed out waiting for an error")
+		}
+
+		if cfg.Host != "staging.internal" {
+			t.Errorf("Watch() Host = %s, want staging.internal", cfg.Host)
+		}
+	})
+}
+
+func TestWatch_PointerSection(t *testing.T) {
+	type testConfig struct {
+		Name string         `yaml:"name"`
+		TLS  *testTLSConfig `yaml:"tls"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("name: base\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	changes := make(chan []string, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := Options().
+		SetEnv(map[string]string{}).
+		SetBasePath(basePath).
+		SetWatchInterval(10 * time.Millisecond)
+
+	cfg := &testConfig{}
+	err := Watch(ctx, opts, cfg, func(old, new any, changed []string) {
+		changes <- changed
+	})
+	if err != nil {
+		t.Fatalf("Watch() unexpected error = %v", err)
+	}
+
+	if cfg.TLS != nil {
+		t.Fatalf("Watch() TLS = %+v, want nil", cfg.TLS)
+	}
+
+	if err := os.WriteFile(basePath, []byte("name: base\ntls:\n  certfile: server.pem\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{"TLS.CertFile", "TLS.Enabled"}) {
+			t.Errorf("Watch() changed = %v, want [TLS.CertFile TLS.Enabled]", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Watch() timed out waiting for a change")
+	}
+
+	if cfg.TLS == nil || cfg.TLS.CertFile != "server.pem" {
+		t.Errorf("Watch() TLS = %+v, want CertFile server.pem", cfg.TLS)
+	}
+}
+
+func TestWatch_Errors(t *testing.T) {
+	t.Parallel()
+
+	type testConfig struct {
+		Name string `yaml:"name"`
+	}
+
+	ctx := context.Background()
+
+	if err := Watch(ctx, Options(), nil, nil); !errors.Is(err, ErrOutNil) {
+		t.Errorf("Watch() error = %v, want ErrOutNil", err)
+	}
+
+	if err := Watch(ctx, Options(), testConfig{}, nil); !errors.Is(err, ErrTypeDiscovery) {
+		t.Errorf("Watch() error = %v, want ErrTypeDiscovery", err)
+	}
+
+	opts := Options().SetBasePath("./does/not/exist.yml")
+	if err := Watch(ctx, opts, &testConfig{}, nil); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("Watch() error = %v, want ErrFileNotFound", err)
+	}
+}
+
+func TestWatch_MapTarget(t *testing.T) {
+	type testService struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+
+	t.Parallel()
+
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.yaml")
+	if err := os.WriteFile(basePath, []byte("orders:\n  host: orders.internal\n  port: 80\nusers:\n  host: users.internal\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	changes := make(chan []string, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	opts := Options().
+		SetArgs([]string{}).
+		SetEnv(map[string]string{}).
+		SetBasePath(basePath).
+		SetWatchInterval(10 * time.Millisecond)
+
+	out := map[string]testService{}
+	err := Watch(ctx, opts, &out, func(old, new any, changed []string) {
+		changes <- changed
+	})
+	if err != nil {
+		t.Fatalf("Watch() unexpected error = %v", err)
+	}
+
+	if err := os.WriteFile(basePath, []byte("orders:\n  host: orders.internal\n  port: 8080\npayments:\n  host: payments.internal\n"), 0o600); err != nil {
+		t.Fatalf("unable to write base file: %v", err)
+	}
+
+	select {
+	case changed := <-changes:
+		if !reflect.DeepEqual(changed, []string{"orders", "payments", "users"}) {
+			t.Errorf("Watch() changed = %v, want [orders payments users]", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Watch() timed out waiting for a change")
+	}
+
+	want := map[string]testService{
+		"orders":   {Host: "orders.internal", Port: 8080},
+		"payments": {Host: "payments.internal"},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Watch() out = %+v, want %+v", out, want)
+	}
+}